@@ -0,0 +1,172 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"time"
+)
+
+// AxisTick is a tick of a time axis.
+type AxisTick struct {
+	Time     time.Time // the time of the tick, matching the mask of its level
+	Position float64   // the progress of the tick within the timeslice, between 0 and 1
+	Label    string    // the time formated according to the mask of its level
+	Major    bool      // true for a major tick, false for a minor tick
+}
+
+// Axis holds the ticks of a time axis drawn over a timeslice.
+type Axis struct {
+	TimeSlice TimeSlice  // the timeslice of the axis, always chronological
+	MajorMask Mask       // the mask of the major ticks, MASK_NONE if the axis has no ticks
+	MinorMask Mask       // the mask of the minor ticks, nil if the major ticks are not subdivided
+	Ticks     []AxisTick // major and minor ticks in chronological order, boundaries are not ticks unless they match a mask
+}
+
+// NewAxis returns the ticks of a time axis drawn over the timeslice, with at most maxLabels major ticks.
+// For an axis of a given pixel width, maxLabels is usually the width divided by the width of a label.
+//
+// The major mask is the one returned by GetScanMask, the minor mask subdivides each major step in 2 to 7 minor steps.
+// Minor ticks matching a major tick are not output.
+//
+// Labels are formated with the Format function of the mask of the tick level, so only what changed since the previous tick is output:
+// a major label is compared to the previous major tick, and the first one is fully qualified.
+// A minor label is compared to the previous tick, whatever its level.
+//
+// Returns an axis without ticks if the timeslice has an infinite boundary, is a single date, or if maxLabels is 0.
+// An anti-chronological timeslice is drawn chronologically.
+func NewAxis(ts TimeSlice, maxLabels uint) Axis {
+	ts.ForceDirection(Chronological)
+	axis := Axis{TimeSlice: ts, MajorMask: MASK_NONE}
+	if ts.IsInfinite() || ts.From.Equal(ts.To) || maxLabels == 0 {
+		return axis
+	}
+	axis.build(ts.GetScanMask(maxLabels), func(mask Mask) []time.Time { return openTimes(mask, []TimeSlice{ts}) }, ts.Progress)
+	return axis
+}
+
+// build generates the ticks of the major mask and of its minor mask at the times returned by times, positioned by position.
+func (axis *Axis) build(major TimeMask, times func(Mask) []time.Time, position func(time.Time) float64) {
+	axis.MajorMask = major
+	axis.MinorMask = minorMask(major)
+
+	majors := times(axis.MajorMask)
+	var minors []time.Time
+	if axis.MinorMask != nil {
+		// both are chronological, minor ticks matching a major tick are skipped
+		i := 0
+		for _, t := range times(axis.MinorMask) {
+			for i < len(majors) && majors[i].Before(t) {
+				i++
+			}
+			if i == len(majors) || !majors[i].Equal(t) {
+				minors = append(minors, t)
+			}
+		}
+	}
+
+	tick := func(t time.Time, formert time.Time, major bool) AxisTick {
+		mask := axis.MinorMask
+		if major {
+			mask = axis.MajorMask
+		}
+		return AxisTick{Time: t, Position: position(t), Label: mask.Format(t, formert), Major: major}
+	}
+
+	var formert, formermajor time.Time
+	for _, major := range majors {
+		for len(minors) > 0 && minors[0].Before(major) {
+			axis.Ticks = append(axis.Ticks, tick(minors[0], formert, false))
+			formert, minors = minors[0], minors[1:]
+		}
+		axis.Ticks = append(axis.Ticks, tick(major, formermajor, true))
+		formert, formermajor = major, major
+	}
+	for _, minor := range minors {
+		axis.Ticks = append(axis.Ticks, tick(minor, formert, false))
+		formert = minor
+	}
+}
+
+// openTimes returns the times matching the mask within the open timeslices.
+// Times at the end of an open timeslice are skipped, unless it is the last one.
+func openTimes(mask Mask, open []TimeSlice) []time.Time {
+	times := make([]time.Time, 0)
+	for i, slice := range open {
+		for t := range slice.Times(mask, false) {
+			if i == len(open)-1 || t.Before(slice.To) {
+				times = append(times, t)
+			}
+		}
+	}
+	return times
+}
+
+// sessionTimes returns the times matching the mask within the open timeslices, see openTimes.
+// If they all fall in closed times, like midnights for a market, returns the first open time of each step of the mask instead,
+// so the begining of the first open timeslice, the session open, within each step.
+func sessionTimes(mask Mask, open []TimeSlice) []time.Time {
+	if times := openTimes(mask, open); len(times) > 0 {
+		return times
+	}
+	times := make([]time.Time, 0)
+	var step time.Time
+	for _, slice := range open {
+		if begining, _ := mask.Apply(slice.From); len(times) == 0 || begining.After(step) {
+			times = append(times, slice.From)
+			step = begining
+		}
+	}
+	return times
+}
+
+// Majors returns the major ticks only.
+func (axis Axis) Majors() []AxisTick {
+	majors := make([]AxisTick, 0)
+	for _, tick := range axis.Ticks {
+		if tick.Major {
+			majors = append(majors, tick)
+		}
+	}
+	return majors
+}
+
+// minorMask returns the mask subdividing the major mask steps, nil if major can not be subdivided
+func minorMask(major TimeMask) Mask {
+	switch major {
+	case MASK_MILLISECONDx100:
+		return StepMask{Unit: UNIT_MILLISECOND, Multiple: 20}
+	case MASK_SECOND:
+		return StepMask{Unit: UNIT_MILLISECOND, Multiple: 200}
+	case MASK_SECONDx5:
+		return MASK_SECOND
+	case MASK_SECONDx10:
+		return StepMask{Unit: UNIT_SECOND, Multiple: 2}
+	case MASK_SECONDx15:
+		return MASK_SECONDx5
+	case MASK_SECONDx30:
+		return MASK_SECONDx10
+	case MASK_MINUTE:
+		return MASK_SECONDx15
+	case MASK_MINUTEx15, MASK_HALFHOUR:
+		return StepMask{Unit: UNIT_MINUTE, Multiple: 5}
+	case MASK_HOUR:
+		return MASK_MINUTEx15
+	case MASK_HOURx4:
+		return MASK_HOUR
+	case MASK_HALFDAY:
+		return MASK_HOURx4
+	case MASK_DAY:
+		return MASK_HOURx4
+	case MASK_WEEK:
+		return MASK_DAY
+	case MASK_MONTH:
+		// days 1, 8, 15, 22 and 29
+		return StepMask{Unit: UNIT_DAY, Multiple: 7}
+	case MASK_QUARTER:
+		return MASK_MONTH
+	case MASK_YEAR:
+		return MASK_QUARTER
+	}
+	return nil
+}
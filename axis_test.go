@@ -0,0 +1,84 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestAxis(t *testing.T) {
+	cases := []struct {
+		ts        TimeSlice
+		maxLabels uint
+		major     Mask
+		minor     Mask
+		nbmajors  int
+		nbticks   int
+		labels    []string
+	}{
+		{MakeTimeSlice(time.Date(2023, 11, 15, 0, 0, 0, 0, time.UTC), 4*Month), 5, MASK_MONTH, StepMask{Unit: UNIT_DAY, Multiple: 7}, 4, 21,
+			[]string{"2023, Dec", "2024, Jan", "Feb", "Mar"}},
+		{MakeTimeSlice(time.Date(2024, 1, 1, 12, 0, 7, 0, time.UTC), 30*time.Second), 4, MASK_SECONDx10, StepMask{Unit: UNIT_SECOND, Multiple: 2}, 3, 15,
+			[]string{"2024, Jan, Mon 01, 12:00:10", "12:00:20", "12:00:30"}},
+		{MakeTimeSlice(time.Date(2024, 1, 31, 3, 0, 0, 0, time.UTC), -5*time.Hour), 6, MASK_HOUR, MASK_MINUTEx15, 6, 21,
+			[]string{"2024, Jan, Tue 30, 22:00", "23:00", "Wed 31, 00:00", "01:00", "02:00", "03:00"}},
+		{MakeTimeSlice(time.Date(2024, 2, 17, 0, 0, 0, 0, time.UTC), 4*Week), 5, MASK_WEEK, MASK_DAY, 4, 29,
+			[]string{"2024-W08", "2024-W09", "2024-W10", "2024-W11"}},
+		{MakeTimeSlice(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 0), 10, MASK_NONE, nil, 0, 0, nil},
+		{TimeSlice{From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}, 10, MASK_NONE, nil, 0, 0, nil},
+		{MakeTimeSlice(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Day), 0, MASK_NONE, nil, 0, 0, nil},
+	}
+	for i, c := range cases {
+		axis := NewAxis(c.ts, c.maxLabels)
+		if axis.MajorMask != c.major || axis.MinorMask != c.minor {
+			t.Errorf("case %d: masks fails: got %v %v", i, axis.MajorMask, axis.MinorMask)
+		}
+		if len(axis.Ticks) != c.nbticks {
+			t.Errorf("case %d: ticks fails: want %d got %d", i, c.nbticks, len(axis.Ticks))
+		}
+		majors := axis.Majors()
+		if len(majors) != c.nbmajors {
+			t.Errorf("case %d: majors fails: want %d got %d", i, c.nbmajors, len(majors))
+			continue
+		}
+		for j, tick := range majors {
+			if tick.Label != c.labels[j] {
+				t.Errorf("case %d: label %d fails: want %q got %q", i, j, c.labels[j], tick.Label)
+			}
+		}
+		for j, tick := range axis.Ticks {
+			if tick.Position < 0 || tick.Position > 1 || (j > 0 && !tick.Time.After(axis.Ticks[j-1].Time)) {
+				t.Errorf("case %d: tick %d fails: got %+v", i, j, tick)
+			}
+			if want := axis.TimeSlice.Progress(tick.Time); tick.Position != want {
+				t.Errorf("case %d: position %d fails: want %f got %f", i, j, want, tick.Position)
+			}
+			if _, ismajor := axis.MajorMask.Apply(tick.Time); ismajor != tick.Major {
+				t.Errorf("case %d: tick %d level fails: got %+v", i, j, tick)
+			}
+		}
+	}
+}
+
+func ExampleNewAxis() {
+	ts := MakeTimeSlice(time.Date(2024, 1, 30, 21, 10, 0, 0, time.UTC), 4*time.Hour)
+
+	// an axis 600 pixels wide, with labels 100 pixels wide
+	axis := NewAxis(ts, 600/100)
+	fmt.Printf("major: %s, minor: %s\n", axis.MajorMask, axis.MinorMask)
+	for _, tick := range axis.Ticks {
+		if tick.Major {
+			fmt.Printf("%5.1f%% %s\n", tick.Position*100, tick.Label)
+		}
+	}
+
+	// Output:
+	// major: hour, minor: 15 minutes
+	//  20.8% 2024, Jan, Tue 30, 22:00
+	//  45.8% 23:00
+	//  70.8% Wed 31, 00:00
+	//  95.8% 01:00
+}
@@ -0,0 +1,175 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Number is a constraint for the values of numeric series
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~float32 | ~float64
+}
+
+// Point is a value of a time series.
+type Point[T any] struct {
+	Time  time.Time
+	Value T
+}
+
+// Bucket is a timeslice with the values of the points within it, aggregated by reducers.
+type Bucket[R any] struct {
+	TimeSlice
+	Count  int // the number of points within the bucket
+	Values []R // one aggregated value per reducer, in the order of the reducers
+}
+
+// Bucketize splits the timeslice in buckets at every time matching mask, and aggregates the values of the points within each bucket
+// with every reducer. Reducers get the values in chronological order, and are also called for empty buckets with no values.
+//
+// The first and the last buckets are partial if the boundaries of the timeslice do not match the mask.
+// A point belongs to the bucket where it is between the begining, included, and the end, excluded, except at the end of the timeslice.
+// Buckets follow the timeslice direction, so an anti-chronological timeslice gives anti-chronological buckets, the latest first.
+// Points out of the timeslice are ignored.
+//
+// returns an error wrapping ErrInfinite if a boundary is infinite, or wrapping ErrInvalidMask if mask can not be used for scanning.
+func Bucketize[T any, R any](ts TimeSlice, mask Mask, points []Point[T], reducers ...func([]T) R) ([]Bucket[R], error) {
+	if err := CheckMask(mask); err != nil {
+		return nil, err
+	}
+	if ts.IsInfinite() {
+		return nil, fmt.Errorf("unable to bucketize an infinite timeslice: %w", ErrInfinite)
+	}
+	chrono := ts
+	chrono.ForceDirection(Chronological)
+	slices := make([]TimeSlice, 0)
+	var from time.Time
+	for t := range chrono.Times(mask, true) {
+		if !from.IsZero() {
+			slices = append(slices, TimeSlice{From: from, To: t})
+		}
+		from = t
+	}
+	return bucketize(slices, ts.Direction() == AntiChronological, points, reducers), nil
+}
+
+// BucketizeDuration splits the timeslice in buckets of d duration like Split does, and aggregates the values of the points within each bucket
+// with every reducer, like Bucketize does. The last bucket is shorter than d if the timeslice duration is not a multiple of d.
+//
+// returns an error wrapping ErrInfinite if a boundary is infinite, or wrapping ErrNonPositiveStep if d is <= 0.
+func BucketizeDuration[T any, R any](ts TimeSlice, d time.Duration, points []Point[T], reducers ...func([]T) R) ([]Bucket[R], error) {
+	slices, err := ts.Split(d)
+	if err != nil {
+		return nil, err
+	}
+	antichrono := ts.Direction() == AntiChronological
+	if antichrono {
+		for i, j := 0, len(slices)-1; i < j; i, j = i+1, j-1 {
+			slices[i], slices[j] = slices[j], slices[i]
+		}
+		for i := range slices {
+			slices[i].ForceDirection(Chronological)
+		}
+	}
+	return bucketize(slices, antichrono, points, reducers), nil
+}
+
+// bucketize aggregates the points within the chronological and contiguous slices, and returns the buckets in the chronological order,
+// or in the anti-chronological order with anti-chronological buckets if antichrono is true.
+func bucketize[T any, R any](slices []TimeSlice, antichrono bool, points []Point[T], reducers []func([]T) R) []Bucket[R] {
+	sorted := append([]Point[T]{}, points...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+
+	values := make([][]T, len(slices))
+	for _, pt := range sorted {
+		if len(slices) == 0 || pt.Time.Before(slices[0].From) {
+			continue
+		}
+		i := sort.Search(len(slices), func(i int) bool { return slices[i].To.After(pt.Time) })
+		if i == len(slices) {
+			if !pt.Time.Equal(slices[i-1].To) {
+				continue
+			}
+			i--
+		}
+		values[i] = append(values[i], pt.Value)
+	}
+
+	buckets := make([]Bucket[R], len(slices))
+	for i, slice := range slices {
+		j := i
+		if antichrono {
+			j = len(slices) - 1 - i
+			slice.ForceDirection(AntiChronological)
+		}
+		buckets[j] = Bucket[R]{TimeSlice: slice, Count: len(values[i]), Values: make([]R, len(reducers))}
+		for k, reduce := range reducers {
+			buckets[j].Values[k] = reduce(values[i])
+		}
+	}
+	return buckets
+}
+
+// SumOf returns the sum of the values, 0 without values.
+func SumOf[T Number](values []T) T {
+	var sum T
+	for _, v := range values {
+		sum += v
+	}
+	return sum
+}
+
+// MeanOf returns the average of the values, NaN without values.
+func MeanOf[T Number](values []T) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	var sum float64
+	for _, v := range values {
+		sum += float64(v)
+	}
+	return sum / float64(len(values))
+}
+
+// MinOf returns the minimum of the values, the zero value without values.
+func MinOf[T cmp.Ordered](values []T) (m T) {
+	for i, v := range values {
+		if i == 0 || v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+// MaxOf returns the maximum of the values, the zero value without values.
+func MaxOf[T cmp.Ordered](values []T) (m T) {
+	for i, v := range values {
+		if i == 0 || v > m {
+			m = v
+		}
+	}
+	return m
+}
+
+// FirstOf returns the first value, the zero value without values.
+func FirstOf[T any](values []T) (first T) {
+	if len(values) > 0 {
+		first = values[0]
+	}
+	return first
+}
+
+// LastOf returns the last value, the zero value without values.
+func LastOf[T any](values []T) (last T) {
+	if len(values) > 0 {
+		last = values[len(values)-1]
+	}
+	return last
+}
@@ -0,0 +1,125 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBucketize(t *testing.T) {
+	dte := func(h, m int) time.Time { return time.Date(2024, 1, 2, h, m, 0, 0, time.UTC) }
+	points := []Point[float64]{
+		{dte(10, 50), 5}, // out of the timeslice
+		{dte(11, 10), 1},
+		{dte(11, 50), 3},
+		{dte(11, 20), 2}, // not sorted
+		{dte(12, 0), 4},
+		{dte(13, 30), 6}, // at the end of the timeslice
+	}
+	ts := TimeSlice{From: dte(11, 0), To: dte(13, 30)}
+
+	buckets, err := Bucketize(ts, MASK_HOUR, points, SumOf[float64], MeanOf[float64], FirstOf[float64], LastOf[float64])
+	if err != nil || len(buckets) != 3 {
+		t.Fatalf("Bucketize fails: got %v %v", buckets, err)
+	}
+	want := []struct {
+		ts     TimeSlice
+		count  int
+		values []float64
+	}{
+		{TimeSlice{From: dte(11, 0), To: dte(12, 0)}, 3, []float64{6, 2, 1, 3}},
+		{TimeSlice{From: dte(12, 0), To: dte(13, 0)}, 1, []float64{4, 4, 4, 4}},
+		{TimeSlice{From: dte(13, 0), To: dte(13, 30)}, 1, []float64{6, 6, 6, 6}},
+	}
+	for i, w := range want {
+		b := buckets[i]
+		if b.TimeSlice != w.ts || b.Count != w.count || fmt.Sprint(b.Values) != fmt.Sprint(w.values) {
+			t.Errorf("Bucketize %d fails: want %v %d %v got %v %d %v", i, w.ts, w.count, w.values, b.TimeSlice, b.Count, b.Values)
+		}
+	}
+
+	// partial first bucket, and empty buckets
+	buckets, _ = Bucketize(TimeSlice{From: dte(10, 30), To: dte(14, 0)}, MASK_HOUR, points, MeanOf[float64])
+	if len(buckets) != 4 || buckets[0].TimeSlice != (TimeSlice{From: dte(10, 30), To: dte(11, 0)}) || buckets[0].Count != 1 {
+		t.Errorf("Bucketize partial fails: got %v", buckets)
+	}
+	if buckets[3].Count != 1 || buckets[2].Count != 1 {
+		t.Errorf("Bucketize partial fails: got %v", buckets)
+	}
+	buckets, _ = Bucketize(MakeTimeSlice(dte(14, 0), 2*time.Hour), MASK_HOUR, points, MeanOf[float64])
+	if len(buckets) != 2 || buckets[0].Count != 0 || !math.IsNaN(buckets[0].Values[0]) {
+		t.Errorf("Bucketize empty fails: got %v", buckets)
+	}
+
+	// anti-chronological
+	anti := ts
+	anti.ForceDirection(AntiChronological)
+	buckets, _ = Bucketize(anti, MASK_HOUR, points, SumOf[float64])
+	if len(buckets) != 3 || buckets[0].TimeSlice != (TimeSlice{From: dte(13, 30), To: dte(13, 0)}) || buckets[2].Values[0] != 6 {
+		t.Errorf("Bucketize antichrono fails: got %v", buckets)
+	}
+
+	// custom masks
+	buckets, _ = Bucketize(ts, StepMask{Unit: UNIT_MINUTE, Multiple: 45}, points, MaxOf[float64])
+	if len(buckets) != 5 || buckets[1].TimeSlice != (TimeSlice{From: dte(11, 45), To: dte(12, 0)}) || buckets[1].Values[0] != 3 {
+		t.Errorf("Bucketize custom mask fails: got %v", buckets)
+	}
+
+	// errors
+	if _, err := Bucketize(TimeSlice{From: dte(11, 0)}, MASK_HOUR, points, SumOf[float64]); !errors.Is(err, ErrInfinite) {
+		t.Errorf("Bucketize infinite fails: got %v", err)
+	}
+	if _, err := Bucketize(ts, MASK_NONE, points, SumOf[float64]); !errors.Is(err, ErrInvalidMask) {
+		t.Errorf("Bucketize invalid mask fails: got %v", err)
+	}
+}
+
+func TestBucketizeDuration(t *testing.T) {
+	dte := func(m int) time.Time { return time.Date(2024, 1, 2, 11, m, 0, 0, time.UTC) }
+	points := []Point[int]{{dte(0), 1}, {dte(5), 2}, {dte(10), 3}, {dte(24), 4}, {dte(25), 5}}
+
+	// like Split, the last bucket is shorter
+	buckets, err := BucketizeDuration(TimeSlice{From: dte(0), To: dte(25)}, 10*time.Minute, points, SumOf[int], MinOf[int])
+	if err != nil || len(buckets) != 3 {
+		t.Fatalf("BucketizeDuration fails: got %v %v", buckets, err)
+	}
+	if buckets[2].TimeSlice != (TimeSlice{From: dte(20), To: dte(25)}) || buckets[2].Values[0] != 9 || buckets[0].Values[1] != 1 {
+		t.Errorf("BucketizeDuration fails: got %v", buckets)
+	}
+
+	// anti-chronological, the last bucket is the earliest one
+	buckets, _ = BucketizeDuration(TimeSlice{From: dte(25), To: dte(0)}, 10*time.Minute, points, SumOf[int])
+	if len(buckets) != 3 || buckets[0].TimeSlice != (TimeSlice{From: dte(25), To: dte(15)}) || buckets[0].Values[0] != 9 {
+		t.Errorf("BucketizeDuration antichrono fails: got %v", buckets)
+	}
+	if buckets[2].TimeSlice != (TimeSlice{From: dte(5), To: dte(0)}) || buckets[2].Count != 1 || buckets[1].Values[0] != 5 {
+		t.Errorf("BucketizeDuration antichrono fails: got %v", buckets)
+	}
+
+	if _, err := BucketizeDuration(TimeSlice{From: dte(0), To: dte(25)}, 0, points, SumOf[int]); !errors.Is(err, ErrNonPositiveStep) {
+		t.Errorf("BucketizeDuration fails: got %v", err)
+	}
+}
+
+func ExampleBucketize() {
+	day := MakeTimeSlice(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), Day)
+	temperatures := []Point[float64]{
+		{Time: day.From.Add(2 * time.Hour), Value: 4.5},
+		{Time: day.From.Add(7 * time.Hour), Value: 6.5},
+		{Time: day.From.Add(14 * time.Hour), Value: 12},
+		{Time: day.From.Add(15 * time.Hour), Value: 11},
+	}
+	buckets, _ := Bucketize(day, MASK_HALFDAY, temperatures, MinOf[float64], MaxOf[float64], MeanOf[float64])
+	for _, b := range buckets {
+		fmt.Printf("%s: count=%d min=%v max=%v mean=%v\n", b.From.Format("15:04"), b.Count, b.Values[0], b.Values[1], b.Values[2])
+	}
+
+	// Output:
+	// 00:00: count=2 min=4.5 max=6.5 mean=5.5
+	// 12:00: count=2 min=11 max=12 mean=11.5
+}
@@ -0,0 +1,451 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ClockRange is a range of times within a day, defined by the wall clock durations since midnight.
+// To can be 24h to end at the next midnight.
+type ClockRange struct {
+	From time.Duration
+	To   time.Duration
+}
+
+// On returns the timeslice of the clock range on the day of dte, in the location of dte.
+// The wall clock is used so the clock range is right across daylight saving time changes.
+func (cr ClockRange) On(dte time.Time) TimeSlice {
+	return TimeSlice{From: clockOn(dte, cr.From), To: clockOn(dte, cr.To)}
+}
+
+// clockOn returns the time at the wall clock d since midnight on the day of dte, in the location of dte
+func clockOn(dte time.Time, d time.Duration) time.Time {
+	return time.Date(dte.Year(), dte.Month(), dte.Day(), 0, 0, int(d/time.Second), int(d%time.Second), dte.Location())
+}
+
+// String returns the clock range formated like "09:00-17:30"
+func (cr ClockRange) String() string {
+	return formatClock(cr.From) + "-" + formatClock(cr.To)
+}
+
+// Calendar describes working days, daily working hours and holidays, in a location.
+type Calendar struct {
+	Location    *time.Location // UTC if nil
+	WorkingDays [7]bool        // indexed by time.Weekday
+	Hours       []ClockRange   // working hours of every working day, the whole day if empty
+	Holidays    TimeSliceSet   // non working periods
+}
+
+// ParseCalendar parses a calendar defined with one directive per line, like:
+//
+//	# comments and empty lines are ignored
+//	location Europe/Paris
+//	days mon-fri
+//	hours 09:00-12:30 13:30-18:00
+//	holiday 2024-12-25
+//	holiday 2024-08-05/2024-08-16
+//	holiday 2024-12-24T12:00/2024-12-24T18:00
+//
+// Days are a list of weekdays or ranges of weekdays, separated by commas, Monday to Friday by default.
+// Without hours, working days are worked all day long.
+// Holidays are a single day, an inclusive range of days, or a range of datetimes, in the location of the calendar.
+func ParseCalendar(text string) (cal Calendar, err error) {
+	common, err := parseCalendarLines(text, "calendar", true, func(line string, fields []string) error {
+		if strings.ToLower(fields[0]) != "hours" {
+			return fmt.Errorf("invalid calendar line: %q", line)
+		}
+		for _, field := range fields[1:] {
+			cr, err := parseClockRange(field, false)
+			if err != nil {
+				return err
+			}
+			cal.Hours = append(cal.Hours, cr)
+		}
+		return nil
+	})
+	if err != nil {
+		return Calendar{}, err
+	}
+	cal.Location, cal.WorkingDays, cal.Holidays = common.location, common.days, common.holidays
+	return cal, nil
+}
+
+// IsWorkingTime returns true if t is on a working day, within the working hours and not in holidays.
+//
+// The begining of the working hours and of holidays is included, but not their end.
+func (cal Calendar) IsWorkingTime(t time.Time) bool {
+	t = t.In(cal.location())
+	if !cal.WorkingDays[t.Weekday()] {
+		return false
+	}
+	for _, holiday := range cal.Holidays {
+		if !startBefore(t, holiday.From) && endAfter(holiday.To, t) {
+			return false
+		}
+	}
+	if len(cal.Hours) == 0 {
+		return true
+	}
+	for _, cr := range cal.Hours {
+		ts := cr.On(t)
+		if !t.Before(ts.From) && t.Before(ts.To) {
+			return true
+		}
+	}
+	return false
+}
+
+// WorkingSlices returns the working times within the timeslice.
+//
+// returns an error wrapping ErrInfinite if the timeslice has an infinite boundary.
+func (cal Calendar) WorkingSlices(within TimeSlice) (TimeSliceSet, error) {
+	if within.IsInfinite() {
+		return TimeSliceSet{}, fmt.Errorf("unable to get working times of an infinite timeslice: %w", ErrInfinite)
+	}
+	within.ForceDirection(Chronological)
+	loc := cal.location()
+
+	hours := cal.Hours
+	if len(hours) == 0 {
+		hours = []ClockRange{{From: 0, To: Day}}
+	}
+	from := within.From.In(loc)
+	working := make(TimeSliceSet, 0)
+	for day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc); day.Before(within.To); day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc) {
+		if !cal.WorkingDays[day.Weekday()] {
+			continue
+		}
+		for _, cr := range hours {
+			working = append(working, cr.On(day))
+		}
+	}
+	return working.Intersect(TimeSliceSet{within}).Subtract(cal.Holidays), nil
+}
+
+// BusinessDuration returns the cumulated duration of working times within the timeslice, always positive.
+//
+// returns an error if the timeslice has an infinite boundary.
+func (cal Calendar) BusinessDuration(within TimeSlice) (Duration, error) {
+	working, err := cal.WorkingSlices(within)
+	if err != nil {
+		return Duration{}, err
+	}
+	return working.Duration(), nil
+}
+
+// IsBusinessDay returns true if the day of t is a working day with some working times, not entirely in holidays.
+func (cal Calendar) IsBusinessDay(t time.Time) bool {
+	t = t.In(cal.location())
+	day := TimeSlice{From: time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())}
+	day.To = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+	working, _ := cal.WorkingSlices(day)
+	return !working.IsEmpty()
+}
+
+// AddBusinessDays moves t by n business days, keeping its time of the day. Moves backward if n is negative.
+//
+// The returned time can be outside of working hours if t is.
+//
+// returns false if there's no business day within 10 years, like with never ending holidays.
+func (cal Calendar) AddBusinessDays(t time.Time, n int) (time.Time, bool) {
+	t = t.In(cal.location())
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for idle := 0; n > 0; idle++ {
+		if idle > 366*10 {
+			return time.Time{}, false
+		}
+		t = time.Date(t.Year(), t.Month(), t.Day()+step, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+		if cal.IsBusinessDay(t) {
+			n--
+			idle = 0
+		}
+	}
+	return t, true
+}
+
+// Split splits the working times of the timeslice in multiple timeslices of a d duration, skipping non working times.
+//
+// Each working period is split like TimeSlice.Split does, in the direction of the timeslice.
+//
+// returns an error wrapping ErrInfinite if a boundary is infinite, or wrapping ErrNonPositiveStep if d is <= 0.
+func (cal Calendar) Split(ts TimeSlice, d time.Duration) ([]TimeSlice, error) {
+	if d <= 0 {
+		return []TimeSlice{}, fmt.Errorf("unable to split a timeslice by %v: %w", d, ErrNonPositiveStep)
+	}
+	working, err := cal.WorkingSlices(ts)
+	if err != nil {
+		return []TimeSlice{}, err
+	}
+	antichrono := ts.Direction() == AntiChronological
+	slices := make([]TimeSlice, 0)
+	for i := range working {
+		period := working[i]
+		if antichrono {
+			period = working[len(working)-1-i]
+			period.ForceDirection(AntiChronological)
+		}
+		split, err := period.Split(d)
+		if err != nil {
+			return []TimeSlice{}, err
+		}
+		slices = append(slices, split...)
+	}
+	return slices, nil
+}
+
+// Scan returns next time, within the timeslice boundaries, matching mask and within working times.
+//
+// Scan works like TimeSlice.Scan, skipping all times out of working times, including boundaries.
+// Non working times are skipped by moving the cursor to the next working times.
+//
+// If the calendar does not have any working times matching the mask within 10 years after the cursor, or before the cursor
+// for an anti-chronological timeslice, Scan returns a zero time and reset the cursor.
+// So does an invalid mask, use ScanErr to get an error in this case.
+func (cal Calendar) Scan(ts TimeSlice, cursor *time.Time, mask Mask, fBoundaries bool) time.Time {
+	if cal.WorkingDays == [7]bool{} {
+		*cursor = time.Time{}
+		return time.Time{}
+	}
+	backward := ts.Direction() == AntiChronological
+	var limit time.Time
+	for {
+		t := ts.Scan(cursor, mask, fBoundaries)
+		if t.IsZero() || cal.IsWorkingTime(t) {
+			return t
+		}
+		if limit.IsZero() {
+			limit = t.AddDate(10, 0, 0)
+			if backward {
+				limit = t.AddDate(-10, 0, 0)
+			}
+		}
+		working, ok := cal.nextWorking(t, backward, limit)
+		if !ok || !ts.To.IsZero() && (!backward && working.From.After(ts.To) || backward && working.To.Before(ts.To)) {
+			*cursor = time.Time{}
+			return time.Time{}
+		}
+		// the next scan returns the first time matching the mask within or after the working times
+		if backward {
+			*cursor = working.To
+		} else {
+			*cursor = working.From.Add(-time.Nanosecond)
+		}
+	}
+}
+
+// ScanErr works like Scan, but returns an error wrapping ErrInvalidMask if mask can not be used for scanning, see CheckMask,
+// so an invalid mask is not mistaken for the end of the scan. The cursor is reset in this case.
+func (cal Calendar) ScanErr(ts TimeSlice, cursor *time.Time, mask Mask, fBoundaries bool) (time.Time, error) {
+	if err := CheckMask(mask); err != nil {
+		*cursor = time.Time{}
+		return time.Time{}, err
+	}
+	return cal.Scan(ts, cursor, mask, fBoundaries), nil
+}
+
+// nextWorking returns the first working times starting after t, or the last one ending before t if backward, with t out of working times.
+//
+// returns false if there's no working times up to limit, or if the holiday containing t never ends.
+func (cal Calendar) nextWorking(t time.Time, backward bool, limit time.Time) (TimeSlice, bool) {
+	for _, holiday := range cal.Holidays {
+		if !startBefore(t, holiday.From) && endAfter(holiday.To, t) && (!backward && holiday.To.IsZero() || backward && holiday.From.IsZero()) {
+			return TimeSlice{}, false
+		}
+	}
+	loc := cal.location()
+	t = t.In(loc)
+	step := 1
+	if backward {
+		step = -1
+	}
+	for day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc); ; day = time.Date(day.Year(), day.Month(), day.Day()+step, 0, 0, 0, 0, loc) {
+		if !backward && day.After(limit) || backward && day.Before(limit) {
+			return TimeSlice{}, false
+		}
+		working, _ := cal.WorkingSlices(TimeSlice{From: day, To: time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc)})
+		for i := range working {
+			if backward {
+				if ws := working[len(working)-1-i]; !ws.To.After(t) {
+					return ws, true
+				}
+			} else if working[i].From.After(t) {
+				return working[i], true
+			}
+		}
+	}
+}
+
+func (cal Calendar) location() *time.Location {
+	if cal.Location == nil {
+		return time.UTC
+	}
+	return cal.Location
+}
+
+// calendarLines are the directives shared by calendars and session calendars
+type calendarLines struct {
+	location *time.Location // UTC by default
+	days     [7]bool        // Monday to Friday by default
+	holidays TimeSliceSet
+}
+
+// parseCalendarLines parses a calendar defined with one directive per line, see ParseCalendar and ParseSessionCalendar.
+// Comments and empty lines are skipped, and the location, days and holiday directives are parsed into the returned calendarLines.
+// Other lines are passed to directive with their fields.
+//
+// Holidays are parsed at the end, in the location. They can be ranges of datetimes only if withtimes is set.
+// name is the kind of calendar in the errors, like "calendar".
+func parseCalendarLines(text string, name string, withtimes bool, directive func(line string, fields []string) error) (cl calendarLines, err error) {
+	for d := time.Monday; d <= time.Friday; d++ {
+		cl.days[d] = true
+	}
+	cl.location = time.UTC
+	holidays := make([]string, 0)
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		switch strings.ToLower(fields[0]) {
+		case "location":
+			if len(fields) != 2 {
+				return calendarLines{}, fmt.Errorf("invalid %s line: %q", name, line)
+			}
+			if cl.location, err = time.LoadLocation(fields[1]); err != nil {
+				return calendarLines{}, err
+			}
+		case "days":
+			if len(fields) != 2 {
+				return calendarLines{}, fmt.Errorf("invalid %s line: %q", name, line)
+			}
+			if cl.days, err = parseWeekdays(fields[1]); err != nil {
+				return calendarLines{}, err
+			}
+		case "holiday":
+			if len(fields) != 2 || !withtimes && strings.Contains(fields[1], "T") {
+				return calendarLines{}, fmt.Errorf("invalid %s line: %q", name, line)
+			}
+			holidays = append(holidays, fields[1])
+		default:
+			if err := directive(line, fields); err != nil {
+				return calendarLines{}, err
+			}
+		}
+	}
+
+	for _, str := range holidays {
+		ts, err := parseDayRange(str, cl.location)
+		if err != nil {
+			return calendarLines{}, err
+		}
+		cl.holidays = append(cl.holidays, ts)
+	}
+	cl.holidays = cl.holidays.Normalize()
+	return cl, nil
+}
+
+// parseWeekdays parses a list of weekdays or weekday ranges like "mon-wed,fri"
+func parseWeekdays(str string) (days [7]bool, err error) {
+	for _, field := range strings.Split(str, ",") {
+		from, to, isrange := strings.Cut(field, "-")
+		if !isrange {
+			to = from
+		}
+		wdfrom, okfrom := weekdays[strings.ToUpper(from[:min(2, len(from))])]
+		wdto, okto := weekdays[strings.ToUpper(to[:min(2, len(to))])]
+		if !okfrom || !okto {
+			return days, fmt.Errorf("invalid weekdays: %q", field)
+		}
+		for wd := wdfrom; ; wd = (wd + 1) % 7 {
+			days[wd] = true
+			if wd == wdto {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// parseClockRange parses a range of wall clock times like "09:00-17:30", or crossing midnight like "18:00-09:30" if overnight is set
+func parseClockRange(str string, overnight bool) (cr ClockRange, err error) {
+	from, to, found := strings.Cut(str, "-")
+	if !found {
+		return cr, fmt.Errorf("invalid clock range: %q", str)
+	}
+	if cr.From, err = parseClock(from); err != nil {
+		return cr, err
+	}
+	if cr.To, err = parseClock(to); err != nil {
+		return cr, err
+	}
+	if cr.To == cr.From || !overnight && cr.To < cr.From {
+		return cr, fmt.Errorf("invalid clock range: %q", str)
+	}
+	return cr, nil
+}
+
+// parseClock parses a wall clock time like "09:30" or "09:30:15", up to "24:00"
+func parseClock(str string) (time.Duration, error) {
+	parts := strings.Split(str, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid clock time: %q", str)
+	}
+	var d time.Duration
+	units := []time.Duration{time.Hour, time.Minute, time.Second}
+	for i, part := range parts {
+		v, err := strconv.Atoi(part)
+		if err != nil || len(part) != 2 || v < 0 || (i > 0 && v > 59) {
+			return 0, fmt.Errorf("invalid clock time: %q", str)
+		}
+		d += time.Duration(v) * units[i]
+	}
+	if d > Day {
+		return 0, fmt.Errorf("invalid clock time: %q", str)
+	}
+	return d, nil
+}
+
+// formatClock formats a wall clock duration since midnight like "09:30", or "09:30:15" with seconds
+func formatClock(d time.Duration) string {
+	h, m, s := int(d/time.Hour), int(d%time.Hour/time.Minute), int(d%time.Minute/time.Second)
+	if s != 0 {
+		return fmt.Sprintf("%02d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%02d:%02d", h, m)
+}
+
+// parseDayRange parses a day "2006-01-02", an inclusive range of days "2006-01-02/2006-01-05",
+// or a range of datetimes "2006-01-02T15:04/2006-01-02T18:00", in loc.
+func parseDayRange(str string, loc *time.Location) (ts TimeSlice, err error) {
+	from, to, isrange := strings.Cut(str, "/")
+	if !isrange {
+		to = from
+	}
+	parse := func(s string, endofday bool) (time.Time, error) {
+		if strings.Contains(s, "T") {
+			return time.ParseInLocation("2006-01-02T15:04", s, loc)
+		}
+		t, err := time.ParseInLocation("2006-01-02", s, loc)
+		if err == nil && endofday {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		}
+		return t, err
+	}
+	if ts.From, err = parse(from, false); err != nil {
+		return TimeSlice{}, fmt.Errorf("invalid day range: %q", str)
+	}
+	if ts.To, err = parse(to, true); err != nil {
+		return TimeSlice{}, fmt.Errorf("invalid day range: %q", str)
+	}
+	return ts, nil
+}
@@ -0,0 +1,178 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+const testCalendar = `
+	# a french office
+	location Europe/Paris
+	days mon-fri
+	hours 09:00-12:30 13:30-18:00
+	holiday 2024-12-25
+	holiday 2024-12-31/2025-01-01
+	holiday 2024-12-24T12:00/2024-12-24T18:00
+`
+
+func TestParseCalendar(t *testing.T) {
+	cal, err := ParseCalendar(testCalendar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cal.Location.String() != "Europe/Paris" || len(cal.Hours) != 2 || cal.Hours[1].String() != "13:30-18:00" || len(cal.Holidays) != 3 {
+		t.Errorf("ParseCalendar fails: %+v", cal)
+	}
+	if cal.WorkingDays[time.Sunday] || !cal.WorkingDays[time.Monday] || !cal.WorkingDays[time.Friday] || cal.WorkingDays[time.Saturday] {
+		t.Errorf("ParseCalendar fails on days: %v", cal.WorkingDays)
+	}
+
+	cal, err = ParseCalendar("days sat-mon,wed")
+	if err != nil || cal.WorkingDays != [7]bool{true, true, false, true, false, false, true} || len(cal.Hours) != 0 {
+		t.Errorf("ParseCalendar fails on days: %v %v", cal.WorkingDays, err)
+	}
+
+	for _, text := range []string{"days mon-xyz", "hours 9h-10h", "hours 10:00-09:00", "hours 09:00-25:00", "holiday 2024-13-01", "location Nowhere/Land", "weekend sat"} {
+		if _, err := ParseCalendar(text); err == nil {
+			t.Errorf("ParseCalendar %q fails: want an error", text)
+		}
+	}
+}
+
+func TestCalendar(t *testing.T) {
+	cal, _ := ParseCalendar(testCalendar)
+	paris := cal.Location
+
+	// working times
+	cases := []struct {
+		t    time.Time
+		want bool
+	}{
+		{time.Date(2024, 12, 23, 9, 0, 0, 0, paris), true},
+		{time.Date(2024, 12, 23, 12, 30, 0, 0, paris), false},
+		{time.Date(2024, 12, 23, 8, 0, 0, 0, time.UTC), true}, // 9:00 in Paris
+		{time.Date(2024, 12, 24, 10, 0, 0, 0, paris), true},
+		{time.Date(2024, 12, 24, 14, 0, 0, 0, paris), false},
+		{time.Date(2024, 12, 25, 10, 0, 0, 0, paris), false},
+		{time.Date(2024, 12, 28, 10, 0, 0, 0, paris), false},
+	}
+	for _, c := range cases {
+		if got := cal.IsWorkingTime(c.t); got != c.want {
+			t.Errorf("IsWorkingTime %v fails: want %v", c.t, c.want)
+		}
+	}
+
+	// business days
+	friday := time.Date(2024, 12, 20, 10, 0, 0, 0, paris)
+	if got, ok := cal.AddBusinessDays(friday, 3); !ok || !got.Equal(time.Date(2024, 12, 26, 10, 0, 0, 0, paris)) {
+		t.Errorf("AddBusinessDays fails: got %v", got)
+	}
+	if got, ok := cal.AddBusinessDays(time.Date(2025, 1, 2, 10, 0, 0, 0, paris), -3); !ok || !got.Equal(time.Date(2024, 12, 26, 10, 0, 0, 0, paris)) {
+		t.Errorf("AddBusinessDays backward fails: got %v", got)
+	}
+	// far away, more than 10 years of business days
+	if got, ok := cal.AddBusinessDays(friday, 261*12); !ok || got.Year() != 2036 {
+		t.Errorf("AddBusinessDays far away fails: got %v", got)
+	}
+	never := cal
+	never.Holidays = NewTimeSliceSet(TimeSlice{From: friday})
+	if got, ok := never.AddBusinessDays(friday, 1); ok || !got.IsZero() {
+		t.Errorf("AddBusinessDays with never ending holidays fails: got %v", got)
+	}
+
+	// business duration of the christmas week: 3 full days plus a half day
+	week := TimeSlice{From: time.Date(2024, 12, 23, 0, 0, 0, 0, paris), To: time.Date(2024, 12, 30, 0, 0, 0, 0, paris)}
+	if d, err := cal.BusinessDuration(week); err != nil || d.Duration != 3*8*time.Hour+3*time.Hour {
+		t.Errorf("BusinessDuration fails: got %v %v", d, err)
+	}
+	if _, err := cal.BusinessDuration(TimeSlice{From: week.From}); err == nil {
+		t.Error("BusinessDuration fails: want an error")
+	}
+
+	// across daylight saving time change
+	dst := TimeSlice{From: time.Date(2024, 3, 29, 0, 0, 0, 0, paris), To: time.Date(2024, 4, 2, 0, 0, 0, 0, paris)}
+	working, _ := cal.WorkingSlices(dst)
+	if len(working) != 4 || working[2].From.In(paris).Hour() != 9 || working[2].From.UTC().Hour() != 7 {
+		t.Errorf("WorkingSlices across DST fails: got %v", working)
+	}
+}
+
+func TestCalendarSplitScan(t *testing.T) {
+	cal, _ := ParseCalendar(testCalendar)
+	paris := cal.Location
+
+	day := TimeSlice{From: time.Date(2024, 12, 23, 8, 0, 0, 0, paris), To: time.Date(2024, 12, 23, 20, 0, 0, 0, paris)}
+	split, err := cal.Split(day, 2*time.Hour)
+	if err != nil || len(split) != 5 || split[1].Duration().Duration != 90*time.Minute || !split[2].From.Equal(time.Date(2024, 12, 23, 13, 30, 0, 0, paris)) {
+		t.Errorf("Split fails: got %v %v", split, err)
+	}
+	split, err = cal.Split(*day.ForceDirection(AntiChronological), 2*time.Hour)
+	if err != nil || len(split) != 5 || !split[0].From.Equal(time.Date(2024, 12, 23, 18, 0, 0, 0, paris)) || split[4].Direction() != AntiChronological {
+		t.Errorf("Split antichrono fails: got %v %v", split, err)
+	}
+
+	var cursor time.Time
+	var got string
+	ts := TimeSlice{From: time.Date(2024, 12, 24, 8, 0, 0, 0, paris), To: time.Date(2024, 12, 26, 11, 0, 0, 0, paris)}
+	for cal.Scan(ts, &cursor, MASK_HOUR, false); !cursor.IsZero(); cal.Scan(ts, &cursor, MASK_HOUR, false) {
+		got += cursor.In(paris).Format("02 15h ")
+	}
+	if got != "24 09h 24 10h 24 11h 26 09h 26 10h 26 11h " {
+		t.Errorf("Scan fails: got %q", got)
+	}
+
+	// backward
+	got = ""
+	antichrono := TimeSlice{From: ts.To, To: ts.From}
+	for cal.Scan(antichrono, &cursor, MASK_HOUR, false); !cursor.IsZero(); cal.Scan(antichrono, &cursor, MASK_HOUR, false) {
+		got += cursor.In(paris).Format("02 15h ")
+	}
+	if got != "26 11h 26 10h 26 09h 24 11h 24 10h 24 09h " {
+		t.Errorf("Scan antichrono fails: got %q", got)
+	}
+
+	// only the first holiday never ends in the past
+	holidays := cal.Holidays
+	cal.Holidays = append(NewTimeSliceSet(TimeSlice{To: time.Date(2024, 1, 1, 0, 0, 0, 0, paris)}), holidays...)
+	got = ""
+	for cal.Scan(ts, &cursor, MASK_HOUR, false); !cursor.IsZero(); cal.Scan(ts, &cursor, MASK_HOUR, false) {
+		got += cursor.In(paris).Format("02 15h ")
+	}
+	if got != "24 09h 24 10h 24 11h 26 09h 26 10h 26 11h " {
+		t.Errorf("Scan with past holidays fails: got %q", got)
+	}
+
+	// never ending holidays
+	cal.Holidays = NewTimeSliceSet(TimeSlice{From: ts.From})
+	cursor = time.Time{}
+	if cal.Scan(TimeSlice{From: ts.From}, &cursor, MASK_HOUR, false); !cursor.IsZero() {
+		t.Errorf("Scan fails with never ending holidays: got %v", cursor)
+	}
+
+	// working hours never matching the mask, with an infinite end
+	cal, _ = ParseCalendar("hours 09:15-09:45")
+	cursor = time.Time{}
+	if cal.Scan(TimeSlice{From: ts.From}, &cursor, MASK_HOUR, false); !cursor.IsZero() {
+		t.Errorf("Scan fails with a mask never matching working hours: got %v", cursor)
+	}
+	if cal.Scan(TimeSlice{From: ts.From}, &cursor, MASK_MINUTEx15, false); !cursor.Equal(time.Date(2024, 12, 24, 9, 15, 0, 0, time.UTC)) {
+		t.Errorf("Scan fails with short working hours: got %v", cursor)
+	}
+}
+
+func ExampleCalendar_AddBusinessDays() {
+	cal, _ := ParseCalendar(`
+		days mon-fri
+		hours 09:00-17:00
+		holiday 2024-12-25`)
+
+	tuesday := time.Date(2024, 12, 24, 10, 0, 0, 0, time.UTC)
+	t, _ := cal.AddBusinessDays(tuesday, 3)
+	fmt.Println(t.Format("Mon 02 Jan 15:04"))
+
+	// Output: Mon 30 Dec 10:00
+}
@@ -0,0 +1,479 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"fmt"
+	"iter"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a schedule defined by a cron expression, matching times at the wall clock of its location.
+//
+// Daylight saving time changes are handled like most cron daemons do:
+//   - a wall clock skipped by a change matches once, at the change, so "30 2 * * *" runs at 03:00 when clocks go forward one hour at 02:00.
+//   - a wall clock repeated by a change matches once, at its first occurrence before the change, unless the hour field is a wildcard
+//     or a step like "*" or "*/2": then both occurrences match, so interval jobs keep running during the repeated hour, like Vixie cron does.
+type Cron struct {
+	Location *time.Location // UTC if nil
+
+	expr        string
+	seconds     uint64 // bitsets of the matching values
+	minutes     uint64
+	hours       uint64
+	days        uint64 // days of the month from 1 to 31
+	months      uint64 // from 1 to 12
+	weekdays    uint64 // from 0 for Sunday to 6 for Saturday
+	anyDay      bool   // the day of the month is not restricted
+	anyWeekday  bool   // the day of the week is not restricted
+	anyHour     bool   // the hour field is a wildcard or a step, matching both occurrences of a repeated hour
+	lastDay     bool   // L, the last day of the month
+	lastWorkday bool   // LW, the last weekday of the month, from Monday to Friday
+	nearest     []int  // nW, the weekday nearest to the nth day of the month
+
+	// d#n the nth weekday d of the month, and dL the last weekday d of the month with N == -1
+	nth []WeekdayNum
+}
+
+// cronHorizon bounds the search in years when an expression does not match any time, like a 30th of February
+const cronHorizon = 400
+
+// cronMaxShift is greater than any daylight saving time change
+const cronMaxShift = 3 * time.Hour
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var cronMonths = []string{"", "JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}
+
+var cronWeekdays = []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}
+
+// ParseCron parses a cron expression matching times at the wall clock of loc, UTC if nil.
+//
+// The expression has 5 fields "minute hour day-of-month month day-of-week", or 6 fields with the seconds first.
+// The macros @yearly, @annually, @monthly, @weekly, @daily, @midnight and @hourly are also accepted.
+//
+// Every field is a list of values separated by commas, where a value can be "*", a number, a range "1-5", or a step
+// like "*/15", "10-40/10" or "5/20" meaning from 5 up to the max. Months can be JAN to DEC and days of the week SUN to SAT,
+// with 0 or 7 for Sunday. "?" is the same as "*" for the day of the month and the day of the week.
+//
+// The day of the month also accepts "L" for the last day of the month, "LW" for the last weekday of the month,
+// and "15W" for the weekday nearest to the 15th within the same month.
+// The day of the week also accepts "5L" for the last Friday of the month, and "1#2" for the second Monday of the month.
+//
+// When both the day of the month and the day of the week are restricted, a day matches if either of them matches, like Vixie cron does.
+func ParseCron(expr string, loc *time.Location) (c Cron, err error) {
+	c.Location = loc
+	c.expr = strings.TrimSpace(expr)
+	spec := c.expr
+	if strings.HasPrefix(spec, "@") {
+		var found bool
+		if spec, found = cronMacros[strings.ToLower(spec)]; !found {
+			return Cron{}, fmt.Errorf("invalid cron expression: %q", expr)
+		}
+	}
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return Cron{}, fmt.Errorf("invalid cron expression: %q", expr)
+	}
+
+	if c.seconds, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return Cron{}, err
+	}
+	if c.minutes, err = parseCronField(fields[1], 0, 59, nil); err != nil {
+		return Cron{}, err
+	}
+	if c.hours, err = parseCronField(fields[2], 0, 23, nil); err != nil {
+		return Cron{}, err
+	}
+	c.anyHour = strings.HasPrefix(fields[2], "*") || strings.Contains(fields[2], "/")
+	if err = c.parseDays(fields[3]); err != nil {
+		return Cron{}, err
+	}
+	if c.months, err = parseCronField(fields[4], 1, 12, cronMonths); err != nil {
+		return Cron{}, err
+	}
+	if err = c.parseWeekdays(fields[5]); err != nil {
+		return Cron{}, err
+	}
+	return c, nil
+}
+
+// String returns the cron expression as parsed.
+func (c Cron) String() string {
+	return c.expr
+}
+
+// Next returns the first time matching the cron after t, excluded.
+//
+// returns a zero time if there's no matching time within the next 400 years.
+func (c Cron) Next(t time.Time) time.Time {
+	return c.next(t, false)
+}
+
+// Prev returns the last time matching the cron before t, excluded.
+//
+// returns a zero time if there's no matching time within the previous 400 years.
+func (c Cron) Prev(t time.Time) time.Time {
+	return c.next(t, true)
+}
+
+// Scan returns next time, within the timeslice boundaries, matching the cron.
+//
+// Scan works like TimeSlice.Scan does with a mask: it starts by the begining of the timeslice, included if it matches,
+// follows the timeslice direction, and moves the cursor to the returned time.
+// If the next matching time is over the timeslice boundary then Scan returns a zero time and reset the cursor.
+//
+// If the begining is infinite, or if there's no more matching time, Scan returns a zero time and reset the cursor.
+func (c Cron) Scan(ts TimeSlice, cursor *time.Time) time.Time {
+	if ts.From.IsZero() {
+		*cursor = time.Time{}
+		return time.Time{}
+	}
+	backward := ts.Direction() == AntiChronological
+	from := *cursor
+	if from.IsZero() {
+		// the begining is included
+		from = ts.From.Add(-time.Nanosecond)
+		if backward {
+			from = ts.From.Add(time.Nanosecond)
+		}
+	}
+	next := c.next(from, backward)
+	if next.IsZero() || !ts.To.IsZero() && (!backward && next.After(ts.To) || backward && next.Before(ts.To)) {
+		*cursor = time.Time{}
+		return time.Time{}
+	}
+	*cursor = next
+	return next
+}
+
+// Times returns an iterator over the times within the timeslice boundaries matching the cron, like successive calls to Scan do.
+//
+// Yields nothing if the begining is infinite. If the end is infinite the iterator never ends by itself, so the caller must break the loop.
+func (c Cron) Times(ts TimeSlice) iter.Seq[time.Time] {
+	return func(yield func(time.Time) bool) {
+		var cursor time.Time
+		for c.Scan(ts, &cursor); !cursor.IsZero(); c.Scan(ts, &cursor) {
+			if !yield(cursor) {
+				return
+			}
+		}
+	}
+}
+
+// next returns the first matching time after t, or the last one before t if backward.
+//
+// Days and wall clocks are processed in UTC, as naive dates, and converted into the location only when matching.
+func (c Cron) next(t time.Time, backward bool) time.Time {
+	t = t.In(c.location())
+	// the wall clock of a time before t can be later than the wall clock of t, within a repeated hour
+	bound := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	if backward {
+		bound = bound.Add(cronMaxShift)
+	} else if c.anyHour {
+		// and the wall clock of a time after t can be earlier, when both occurrences of a repeated hour match
+		bound = bound.Add(-cronMaxShift)
+	}
+	day := time.Date(bound.Year(), bound.Month(), bound.Day(), 0, 0, 0, 0, time.UTC)
+	for day.Year() <= t.Year()+cronHorizon && day.Year() >= t.Year()-cronHorizon {
+		if c.months&(1<<uint(day.Month())) == 0 {
+			// move to the first day of the next month, or to the last day of the previous month
+			if backward {
+				day = time.Date(day.Year(), day.Month(), 0, 0, 0, 0, 0, time.UTC)
+			} else {
+				day = time.Date(day.Year(), day.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			}
+			continue
+		}
+		if c.matchDay(day) {
+			if found := c.onDay(day, t, bound, backward); !found.IsZero() {
+				return found
+			}
+		}
+		if backward {
+			day = day.AddDate(0, 0, -1)
+		} else {
+			day = day.AddDate(0, 0, 1)
+		}
+	}
+	return time.Time{}
+}
+
+// onDay returns the first time of the day matching the cron after t, or the last one before t if backward.
+// Wall clocks up to bound, or after bound if backward, are skipped as they can not match.
+func (c Cron) onDay(day time.Time, t time.Time, bound time.Time, backward bool) time.Time {
+	// the first matching other occurrence of a repeated wall clock
+	var repeated time.Time
+	for _, h := range cronBits(c.hours, 23, backward) {
+		hour := day.Add(time.Duration(h) * time.Hour)
+		if !backward && !hour.Add(time.Hour).After(bound) || backward && hour.After(bound) {
+			continue
+		}
+		for _, m := range cronBits(c.minutes, 59, backward) {
+			for _, s := range cronBits(c.seconds, 59, backward) {
+				wall := hour.Add(time.Duration(m)*time.Minute + time.Duration(s)*time.Second)
+				if !backward && !wall.After(bound) || backward && wall.After(bound) {
+					continue
+				}
+				at := cronTime(wall, t.Location())
+				if c.anyHour {
+					// the first occurrences follow the wall clocks, and so do the second ones, but the second occurrence
+					// of a repeated wall clock comes after the first occurrence of the next wall clocks
+					if second := secondOccurrence(at); !second.Equal(at) {
+						other := second
+						if backward {
+							at, other = second, at
+						}
+						if repeated.IsZero() && (!backward && other.After(t) || backward && other.Before(t)) {
+							repeated = other
+						}
+					}
+				}
+				if !backward && at.After(t) || backward && at.Before(t) {
+					if !repeated.IsZero() && (!backward && repeated.Before(at) || backward && repeated.After(at)) {
+						return repeated
+					}
+					return at
+				}
+			}
+		}
+	}
+	return repeated
+}
+
+// matchDay returns true if the day, a naive date in UTC, matches the day of the month and the day of the week
+func (c Cron) matchDay(day time.Time) bool {
+	if c.anyDay && c.anyWeekday {
+		return true
+	}
+	y, m, d := day.Date()
+	ndays := daysIn(y, m)
+
+	dom := c.days&(1<<uint(d)) != 0 || c.lastDay && d == ndays || c.lastWorkday && d == nearestWeekday(y, m, ndays)
+	for _, n := range c.nearest {
+		dom = dom || d == nearestWeekday(y, m, n)
+	}
+
+	wd := day.Weekday()
+	dow := c.weekdays&(1<<uint(wd)) != 0
+	for _, wdn := range c.nth {
+		if wdn.Weekday == wd && (wdn.N > 0 && (d-1)/7+1 == wdn.N || wdn.N < 0 && d+7 > ndays) {
+			dow = true
+		}
+	}
+
+	switch {
+	case c.anyDay:
+		return dow
+	case c.anyWeekday:
+		return dom
+	}
+	return dom || dow
+}
+
+func (c Cron) location() *time.Location {
+	if c.Location == nil {
+		return time.UTC
+	}
+	return c.Location
+}
+
+// parseDays parses the day of the month field, with the L, LW and nW extensions
+func (c *Cron) parseDays(field string) error {
+	if field == "*" || field == "?" {
+		c.anyDay = true
+		return nil
+	}
+	for _, item := range strings.Split(field, ",") {
+		switch {
+		case strings.EqualFold(item, "L"):
+			c.lastDay = true
+		case strings.EqualFold(item, "LW"):
+			c.lastWorkday = true
+		case len(item) > 1 && strings.HasSuffix(strings.ToUpper(item), "W"):
+			n, err := strconv.Atoi(item[:len(item)-1])
+			if err != nil || n < 1 || n > 31 {
+				return fmt.Errorf("invalid cron day of month: %q", item)
+			}
+			c.nearest = append(c.nearest, n)
+		default:
+			bits, err := parseCronItem(item, 1, 31, nil)
+			if err != nil {
+				return err
+			}
+			c.days |= bits
+		}
+	}
+	return nil
+}
+
+// parseWeekdays parses the day of the week field, with the dL and d#n extensions
+func (c *Cron) parseWeekdays(field string) error {
+	if field == "*" || field == "?" {
+		c.anyWeekday = true
+		return nil
+	}
+	for _, item := range strings.Split(field, ",") {
+		switch {
+		case strings.Contains(item, "#"):
+			str, nth, _ := strings.Cut(item, "#")
+			wd, err := parseCronValue(str, 0, 7, cronWeekdays)
+			if err != nil {
+				return err
+			}
+			n, err := strconv.Atoi(nth)
+			if err != nil || n < 1 || n > 5 {
+				return fmt.Errorf("invalid cron day of week: %q", item)
+			}
+			c.nth = append(c.nth, WeekdayNum{Weekday: time.Weekday(wd % 7), N: n})
+		case len(item) > 1 && strings.HasSuffix(strings.ToUpper(item), "L"):
+			wd, err := parseCronValue(item[:len(item)-1], 0, 7, cronWeekdays)
+			if err != nil {
+				return err
+			}
+			c.nth = append(c.nth, WeekdayNum{Weekday: time.Weekday(wd % 7), N: -1})
+		default:
+			bits, err := parseCronItem(item, 0, 7, cronWeekdays)
+			if err != nil {
+				return err
+			}
+			// 7 is also Sunday
+			if bits&(1<<7) != 0 {
+				bits = bits&^(1<<7) | 1
+			}
+			c.weekdays |= bits
+		}
+	}
+	return nil
+}
+
+// parseCronField parses a list of values, ranges and steps separated by commas, within [min, max], into a bitset
+func parseCronField(field string, min int, max int, names []string) (bits uint64, err error) {
+	for _, item := range strings.Split(field, ",") {
+		b, err := parseCronItem(item, min, max, names)
+		if err != nil {
+			return 0, err
+		}
+		bits |= b
+	}
+	return bits, nil
+}
+
+// parseCronItem parses a single value, a range or a step like "*", "5", "1-5", "*/15", "10-40/10" or "5/20", into a bitset
+func parseCronItem(item string, min int, max int, names []string) (bits uint64, err error) {
+	rng, strstep, hasstep := strings.Cut(item, "/")
+	lo, hi := min, max
+	if rng != "*" {
+		from, to, hasrange := strings.Cut(rng, "-")
+		if lo, err = parseCronValue(from, min, max, names); err != nil {
+			return 0, err
+		}
+		hi = lo
+		switch {
+		case hasrange:
+			if hi, err = parseCronValue(to, min, max, names); err != nil {
+				return 0, err
+			}
+		case hasstep:
+			hi = max
+		}
+	}
+	step := 1
+	if hasstep {
+		if step, err = strconv.Atoi(strstep); err != nil || step <= 0 {
+			return 0, fmt.Errorf("invalid cron step: %q", item)
+		}
+	}
+	if lo > hi {
+		return 0, fmt.Errorf("invalid cron range: %q", item)
+	}
+	for v := lo; v <= hi; v += step {
+		bits |= 1 << uint(v)
+	}
+	return bits, nil
+}
+
+// parseCronValue parses a number within [min, max], or a name if names are given, like "MON" or "jan"
+func parseCronValue(str string, min int, max int, names []string) (int, error) {
+	for i, name := range names {
+		if name != "" && strings.EqualFold(name, str) {
+			return i, nil
+		}
+	}
+	v, err := strconv.Atoi(str)
+	if err != nil || v < min || v > max {
+		return 0, fmt.Errorf("invalid cron value: %q", str)
+	}
+	return v, nil
+}
+
+// cronBits returns the values of the bitset from 0 to max, in the ascending order or descending if backward
+func cronBits(bits uint64, max int, backward bool) []int {
+	values := make([]int, 0, max+1)
+	for v := 0; v <= max; v++ {
+		if bits&(1<<uint(v)) != 0 {
+			values = append(values, v)
+		}
+	}
+	if backward {
+		for i, j := 0, len(values)-1; i < j; i, j = i+1, j-1 {
+			values[i], values[j] = values[j], values[i]
+		}
+	}
+	return values
+}
+
+// cronTime returns the time at the wall clock, a naive time in UTC, in loc.
+// A wall clock skipped by a daylight saving time change returns the time of the change,
+// and a wall clock repeated by a change returns its first occurrence.
+func cronTime(wall time.Time, loc *time.Location) time.Time {
+	t := time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), 0, loc)
+	got := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	if !got.Equal(wall) {
+		// skipped wall clock: time.Date returns a time before or after the change
+		start, end := t.ZoneBounds()
+		if got.Before(wall) {
+			return end
+		}
+		return start
+	}
+	return firstOccurrence(t)
+}
+
+// nearestWeekday returns the day of the month of the weekday, from Monday to Friday, nearest to the nth day of the month, within the same month.
+//
+// returns 0 if the month does not have a nth day.
+func nearestWeekday(y int, m time.Month, n int) int {
+	ndays := daysIn(y, m)
+	if n > ndays {
+		return 0
+	}
+	switch time.Date(y, m, n, 0, 0, 0, 0, time.UTC).Weekday() {
+	case time.Saturday:
+		if n == 1 {
+			return 3
+		}
+		return n - 1
+	case time.Sunday:
+		if n == ndays {
+			return n - 2
+		}
+		return n + 1
+	}
+	return n
+}
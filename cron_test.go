@@ -0,0 +1,192 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	valids := []string{"* * * * *", "0 0 * * *", "*/15 9-17 * * MON-FRI", "0 0 L * ?", "0 0 LW,15W * *", "0 0 ? * 5L,MON#2", "30 */5 * * * *", "0 0 1 jan-jun/2 sun", "@daily", "@Yearly"}
+	for _, expr := range valids {
+		if c, err := ParseCron(expr, nil); err != nil || c.String() != expr {
+			t.Errorf("ParseCron %q fails: %v", expr, err)
+		}
+	}
+	invalids := []string{"", "* * * *", "* * * * * * *", "60 * * * *", "* 24 * * *", "* * 32 * *", "* * 0 * *", "* * * 13 *", "* * * * 8",
+		"5-1 * * * *", "*/0 * * * *", "? * * * *", "* * * * 1#6", "* * 0W * *", "* * 32W * *", "* * * FOO *", "* * * * XL", "@reboot", "1,,2 * * * *"}
+	for _, expr := range invalids {
+		if _, err := ParseCron(expr, nil); err == nil {
+			t.Errorf("ParseCron %q fails: want an error", expr)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	jan1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) // a monday
+	tests := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"* * * * *", jan1, time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC)},
+		{"30 * * * * *", jan1, time.Date(2024, 1, 1, 0, 0, 30, 0, time.UTC)},
+		{"5/20 * * * *", jan1, time.Date(2024, 1, 1, 0, 5, 0, 0, time.UTC)},
+		{"*/15 9-17 * * MON-FRI", jan1, time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)},
+		{"*/15 9-17 * * MON-FRI", time.Date(2024, 1, 5, 17, 45, 0, 0, time.UTC), time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC)},
+		{"@hourly", jan1, time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)},
+		{"@weekly", jan1, time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", jan1, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 */3 *", jan1, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 ? * 7", jan1, time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * FRI", jan1, time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 0 L * *", time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 LW * *", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 15W * *", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 14, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1W * *", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30W * *", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 28, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 5L", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * MON#2", jan1, time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", jan1, time.Time{}},
+	}
+	for _, test := range tests {
+		c, err := ParseCron(test.expr, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := c.Next(test.from); !got.Equal(test.want) {
+			t.Errorf("Next %q from %v fails: want %v got %v", test.expr, test.from, test.want, got)
+		}
+		// Prev is the inverse of Next
+		if !test.want.IsZero() {
+			if got := c.Prev(test.want.Add(time.Second)); !got.Equal(test.want) {
+				t.Errorf("Prev %q fails: want %v got %v", test.expr, test.want, got)
+			}
+		}
+	}
+
+	c, _ := ParseCron("0 9 * * MON-FRI", nil)
+	if got, want := c.Prev(time.Date(2024, 1, 8, 8, 0, 0, 0, time.UTC)), time.Date(2024, 1, 5, 9, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Prev fails: want %v got %v", want, got)
+	}
+}
+
+func TestCronDST(t *testing.T) {
+	ny, _ := time.LoadLocation("America/New_York")
+
+	// clocks go forward at 02:00 on 2024-03-10, and back at 02:00 on 2024-11-03
+	c, _ := ParseCron("30 2 * * *", ny)
+	if got, want := c.Next(time.Date(2024, 3, 10, 0, 0, 0, 0, ny)), time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Next skipped wall clock fails: want %v got %v", want, got)
+	}
+	if got, want := c.Next(time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC)), time.Date(2024, 3, 11, 6, 30, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Next after a skipped wall clock fails: want %v got %v", want, got)
+	}
+	c, _ = ParseCron("30 1 * * *", ny)
+	if got, want := c.Next(time.Date(2024, 11, 3, 0, 0, 0, 0, ny)), time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Next repeated wall clock fails: want %v got %v", want, got)
+	}
+	if got, want := c.Next(time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC)), time.Date(2024, 11, 4, 6, 30, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Next after a repeated wall clock fails: want %v got %v", want, got)
+	}
+
+	c, _ = ParseCron("*/30 * * * *", ny)
+	tests := []struct {
+		within TimeSlice
+		want   []int // minutes since the begining
+	}{
+		{TimeSlice{From: time.Date(2024, 3, 10, 5, 0, 0, 0, time.UTC), To: time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)}, []int{0, 30, 60, 90, 120, 150, 180}},
+		{TimeSlice{From: time.Date(2024, 11, 3, 4, 0, 0, 0, time.UTC), To: time.Date(2024, 11, 3, 8, 0, 0, 0, time.UTC)}, []int{0, 30, 60, 90, 120, 150, 180, 210, 240}},
+		{TimeSlice{From: time.Date(2024, 11, 3, 8, 0, 0, 0, time.UTC), To: time.Date(2024, 11, 3, 4, 0, 0, 0, time.UTC)}, []int{0, -30, -60, -90, -120, -150, -180, -210, -240}},
+	}
+	for _, test := range tests {
+		got := make([]int, 0)
+		for at := range c.Times(test.within) {
+			got = append(got, int(at.Sub(test.within.From)/time.Minute))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("Times %v fails: want %v got %v", test.within, test.want, got)
+		}
+	}
+
+	// both occurrences of a repeated hour match with a wildcard hour
+	if got, want := c.Prev(time.Date(2024, 11, 3, 6, 15, 0, 0, time.UTC)), time.Date(2024, 11, 3, 6, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Prev within a repeated hour fails: want %v got %v", want, got)
+	}
+	if got, want := c.Next(time.Date(2024, 11, 3, 5, 45, 0, 0, time.UTC)), time.Date(2024, 11, 3, 6, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Next within a repeated hour fails: want %v got %v", want, got)
+	}
+
+	// clocks go back at 03:00 CEST on 2024-10-27 in Paris, 02:00 and 02:30 are repeated
+	paris, _ := time.LoadLocation("Europe/Paris")
+	c, _ = ParseCron("0 */30 * * * *", paris)
+	got := make([]string, 0)
+	for at := range c.Times(TimeSlice{From: time.Date(2024, 10, 26, 23, 30, 0, 0, time.UTC), To: time.Date(2024, 10, 27, 2, 0, 0, 0, time.UTC)}) {
+		got = append(got, at.In(paris).Format("15:04 MST"))
+	}
+	if want := "[01:30 CEST 02:00 CEST 02:30 CEST 02:00 CET 02:30 CET 03:00 CET]"; fmt.Sprint(got) != want {
+		t.Errorf("Times within a repeated hour fails: want %v got %v", want, got)
+	}
+	c, _ = ParseCron("0 30 2 * * *", paris)
+	if got, want := c.Next(time.Date(2024, 10, 27, 0, 30, 0, 0, time.UTC)), time.Date(2024, 10, 28, 1, 30, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Next after a repeated wall clock fails: want %v got %v", want, got)
+	}
+}
+
+func TestCronScan(t *testing.T) {
+	c, _ := ParseCron("0 * * * *", nil)
+	noon := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var cursor time.Time
+	got := make([]int, 0)
+	for c.Scan(TimeSlice{From: noon, To: noon.Add(-2 * time.Hour)}, &cursor); !cursor.IsZero(); c.Scan(TimeSlice{From: noon, To: noon.Add(-2 * time.Hour)}, &cursor) {
+		got = append(got, cursor.Hour())
+	}
+	if fmt.Sprint(got) != "[12 11 10]" {
+		t.Errorf("Scan antichronological fails: got %v", got)
+	}
+
+	got = got[:0]
+	for at := range c.Times(TimeSlice{From: noon.Add(time.Second), To: noon.Add(150 * time.Minute)}) {
+		got = append(got, at.Hour())
+	}
+	if fmt.Sprint(got) != "[13 14]" {
+		t.Errorf("Times fails: got %v", got)
+	}
+
+	cursor = noon
+	if c.Scan(TimeSlice{To: noon}, &cursor); !cursor.IsZero() {
+		t.Errorf("Scan with an infinite begining fails: got %v", cursor)
+	}
+	n := 0
+	for range c.Times(TimeSlice{From: noon}) {
+		if n++; n == 10 {
+			break
+		}
+	}
+	if n != 10 {
+		t.Errorf("Times with an infinite end fails: got %d", n)
+	}
+}
+
+func ExampleCron_Times() {
+	paris, _ := time.LoadLocation("Europe/Paris")
+	c, _ := ParseCron("0 9 * * MON-FRI", paris)
+
+	// the next runs within a week, across the daylight saving time change
+	within := MakeTimeSlice(time.Date(2024, 3, 29, 0, 0, 0, 0, paris), Week)
+	for at := range c.Times(within) {
+		fmt.Println(at.Format("Mon 2006-01-02 15:04 MST"))
+	}
+
+	// Output:
+	// Fri 2024-03-29 09:00 CET
+	// Mon 2024-04-01 09:00 CEST
+	// Tue 2024-04-02 09:00 CEST
+	// Wed 2024-04-03 09:00 CEST
+	// Thu 2024-04-04 09:00 CEST
+}
@@ -0,0 +1,147 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// DiscontinuousScale maps the open times of a domain onto a numeric range like pixels, and back, skipping closed times
+// like nights and weekends of a market. Closed periods are collapsed, so open times are evenly spread over the range.
+//
+// The domain is always chronological, use Min greater than Max to invert the scale.
+type DiscontinuousScale struct {
+	Min   float64 // the value of the first open time
+	Max   float64 // the value of the last open time
+	Clamp bool    // bound values within the range, and times within the open times
+
+	domain  TimeSlice
+	open    TimeSliceSet    // open times within the domain
+	offsets []time.Duration // cumulated open duration at the begining of each open timeslice
+	total   time.Duration   // cumulated open duration
+}
+
+// NewDiscontinuousScale returns a scale mapping the open times within the domain onto the range [min, max].
+// Use Calendar.WorkingSlices to get the open times of a calendar.
+//
+// returns an error wrapping ErrInfinite if the domain has an infinite boundary, or an error if there's no open times within the domain.
+func NewDiscontinuousScale(domain TimeSlice, open TimeSliceSet, min float64, max float64) (DiscontinuousScale, error) {
+	if domain.IsInfinite() {
+		return DiscontinuousScale{}, fmt.Errorf("unable to scale an infinite domain: %w", ErrInfinite)
+	}
+	domain.ForceDirection(Chronological)
+	s := DiscontinuousScale{Min: min, Max: max, domain: domain}
+	s.open = open.Intersect(NewTimeSliceSet(domain))
+	if s.open.IsEmpty() {
+		return DiscontinuousScale{}, errors.New("unable to scale a domain without open times")
+	}
+	s.offsets = make([]time.Duration, len(s.open))
+	for i, ts := range s.open {
+		s.offsets[i] = s.total
+		s.total += ts.To.Sub(ts.From)
+	}
+	return s, nil
+}
+
+// Domain returns the chronological domain of the scale.
+func (s DiscontinuousScale) Domain() TimeSlice {
+	return s.domain
+}
+
+// Open returns the open times within the domain.
+func (s DiscontinuousScale) Open() TimeSliceSet {
+	return s.open
+}
+
+// Duration returns the cumulated duration of the open times within the domain.
+func (s DiscontinuousScale) Duration() time.Duration {
+	return s.total
+}
+
+// Position returns the value corresponding to t, with a nanosecond precision.
+// A closed time gets the value of the end of the previous open timeslice, which is also the begining of the next one.
+// A time out of the open times is extrapolated, unless Clamp is set then the returned value is bounded within the range.
+func (s DiscontinuousScale) Position(t time.Time) float64 {
+	if s.total == 0 {
+		return (s.Min + s.Max) / 2
+	}
+	// the first open timeslice ending after t
+	i := sort.Search(len(s.open), func(i int) bool { return s.open[i].To.After(t) })
+	var offset time.Duration
+	switch {
+	case i == len(s.open):
+		offset = s.total + t.Sub(s.open[i-1].To)
+	case i == 0 || t.After(s.open[i].From):
+		offset = s.offsets[i] + t.Sub(s.open[i].From)
+	default:
+		offset = s.offsets[i]
+	}
+	v := s.Min + float64(offset)/float64(s.total)*(s.Max-s.Min)
+	if s.Clamp {
+		v = math.Max(math.Min(s.Min, s.Max), math.Min(math.Max(s.Min, s.Max), v))
+	}
+	return v
+}
+
+// Progress returns the rate of open times elapsed at t, between 0 and 1, like TimeSlice.Progress does without closed times.
+func (s DiscontinuousScale) Progress(t time.Time) float64 {
+	s.Min, s.Max, s.Clamp = 0, 1, true
+	return s.Position(t)
+}
+
+// Time returns the open time corresponding to the value v, rounded to the nanosecond.
+// The value between two open timeslices returns the begining of the next one.
+// A value out of the range is extrapolated, unless Clamp is set then the returned time is bounded within the open times.
+//
+// If the range is empty then returns the first open time.
+func (s DiscontinuousScale) Time(v float64) time.Time {
+	if len(s.open) == 0 {
+		return time.Time{}
+	}
+	if s.Max == s.Min {
+		return s.open[0].From
+	}
+	offset := time.Duration(math.Round((v - s.Min) / (s.Max - s.Min) * float64(s.total)))
+	if s.Clamp {
+		offset = max(0, min(s.total, offset))
+	}
+	// the last open timeslice starting at or before offset
+	i := sort.Search(len(s.offsets), func(i int) bool { return s.offsets[i] > offset }) - 1
+	if i < 0 {
+		return s.open[0].From.Add(offset)
+	}
+	return s.open[i].From.Add(offset - s.offsets[i])
+}
+
+// WhatTime returns the open time at a certain rate of open times, like TimeSlice.WhatTime does without closed times.
+func (s DiscontinuousScale) WhatTime(rate float64) time.Time {
+	s.Min, s.Max, s.Clamp = 0, 1, true
+	return s.Time(rate)
+}
+
+// Axis returns the ticks of a time axis drawn with the scale, with at most maxLabels major ticks, like NewAxis does.
+// The masks are chosen according to the cumulated open duration, and ticks only land on open times.
+// The end of an open timeslice is not a tick, as it has the same position than the begining of the next one, except for the last one.
+//
+// When all the ticks of a mask fall in closed times, like midnights for a market open during the day, the ticks of this level
+// are the session opens, the begining of the first open timeslice within each step of the mask.
+// In this case the major mask is lengthened until there's at most maxLabels major ticks, like weeks rather than days.
+func (s DiscontinuousScale) Axis(maxLabels uint) Axis {
+	axis := Axis{TimeSlice: s.domain, MajorMask: MASK_NONE}
+	if s.total == 0 || maxLabels == 0 {
+		return axis
+	}
+	major := MakeTimeSlice(s.open[0].From, s.total).GetScanMask(maxLabels)
+	if len(openTimes(major, s.open)) == 0 {
+		for major.rank() < MASK_YEAR.rank() && len(sessionTimes(major, s.open)) > int(maxLabels) {
+			major = masksByDuration[major.rank()]
+		}
+	}
+	axis.build(major, func(mask Mask) []time.Time { return sessionTimes(mask, s.open) }, s.Position)
+	return axis
+}
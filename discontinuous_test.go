@@ -0,0 +1,128 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDiscontinuousScale(t *testing.T) {
+	dte := func(d, h, m int) time.Time { return time.Date(2024, 1, d, h, m, 0, 0, time.UTC) }
+
+	// two sessions of 8 hours, on friday 5th and monday 8th
+	open := NewTimeSliceSet(TimeSlice{From: dte(5, 9, 0), To: dte(5, 17, 0)}, TimeSlice{From: dte(8, 9, 0), To: dte(8, 17, 0)})
+	s, err := NewDiscontinuousScale(TimeSlice{From: dte(5, 0, 0), To: dte(9, 0, 0)}, open, 0, 1600)
+	if err != nil || s.Duration() != 16*time.Hour {
+		t.Fatalf("NewDiscontinuousScale fails: %v %v", s.Duration(), err)
+	}
+
+	cases := []struct {
+		t time.Time
+		v float64
+	}{
+		{dte(5, 9, 0), 0},
+		{dte(5, 13, 0), 400},
+		{dte(8, 9, 0), 800},
+		{dte(8, 10, 30), 950},
+		{dte(8, 17, 0), 1600},
+	}
+	for _, c := range cases {
+		if got := s.Position(c.t); got != c.v {
+			t.Errorf("Position %v fails: want %v got %v", c.t, c.v, got)
+		}
+		if got := s.Time(c.v); !got.Equal(c.t) {
+			t.Errorf("Time %v fails: want %v got %v", c.v, c.t, got)
+		}
+	}
+
+	// closed times are collapsed
+	for _, closed := range []time.Time{dte(5, 17, 0), dte(6, 12, 0), dte(8, 8, 59)} {
+		if got := s.Position(closed); got != 800 {
+			t.Errorf("Position %v fails: got %v", closed, got)
+		}
+	}
+
+	// out of open times
+	if got := s.Position(dte(5, 8, 0)); got != -100 {
+		t.Errorf("Position before fails: got %v", got)
+	}
+	if got := s.Time(1700); !got.Equal(dte(8, 18, 0)) {
+		t.Errorf("Time after fails: got %v", got)
+	}
+	s.Clamp = true
+	if got := s.Position(dte(5, 8, 0)); got != 0 {
+		t.Errorf("Position clamp fails: got %v", got)
+	}
+	if got := s.Time(1700); !got.Equal(dte(8, 17, 0)) {
+		t.Errorf("Time clamp fails: got %v", got)
+	}
+	if got := s.Progress(dte(8, 13, 0)); got != 0.75 {
+		t.Errorf("Progress fails: got %v", got)
+	}
+	if got := s.WhatTime(0.25); !got.Equal(dte(5, 13, 0)) {
+		t.Errorf("WhatTime fails: got %v", got)
+	}
+
+	// ticks only land on open times
+	axis := s.Axis(10)
+	if axis.MajorMask != MASK_HOURx4 || len(axis.Majors()) != 4 {
+		t.Errorf("Axis fails: got %s %v", axis.MajorMask, axis.Majors())
+	}
+	for _, tick := range axis.Ticks {
+		if !open.Contains(tick.Time) || tick.Position != s.Position(tick.Time) {
+			t.Errorf("Axis tick fails: got %+v", tick)
+		}
+	}
+
+	// a market session calendar, midnights are closed so majors are the session opens
+	cal, _ := ParseCalendar("location America/New_York\ndays mon-fri\nhours 09:30-16:00")
+	ny := cal.Location
+	month := TimeSlice{From: time.Date(2024, 3, 4, 0, 0, 0, 0, ny), To: time.Date(2024, 3, 30, 0, 0, 0, 0, ny)}
+	sessions, _ := cal.WorkingSlices(month)
+	s, _ = NewDiscontinuousScale(month, sessions, 0, 1000)
+	axis = s.Axis(10)
+	majors := axis.Majors()
+	if axis.MajorMask != MASK_WEEK || len(majors) != 4 || len(axis.Ticks) != len(sessions) {
+		t.Errorf("Axis with sessions fails: got %s %d majors %d ticks", axis.MajorMask, len(majors), len(axis.Ticks))
+	}
+	for i, tick := range majors {
+		if want := time.Date(2024, 3, 4+7*i, 9, 30, 0, 0, ny); !tick.Time.Equal(want) || tick.Label != fmt.Sprintf("2024-W%02d", 10+i) {
+			t.Errorf("Axis with sessions major %d fails: want %v got %+v", i, want, tick)
+		}
+	}
+	for _, tick := range axis.Ticks {
+		if !sessions.Contains(tick.Time) || tick.Time.Hour() != 9 || tick.Time.Minute() != 30 {
+			t.Errorf("Axis with sessions tick fails: got %+v", tick)
+		}
+	}
+
+	// errors
+	if _, err := NewDiscontinuousScale(TimeSlice{From: dte(5, 0, 0)}, open, 0, 1); !errors.Is(err, ErrInfinite) {
+		t.Errorf("NewDiscontinuousScale infinite fails: got %v", err)
+	}
+	if _, err := NewDiscontinuousScale(TimeSlice{From: dte(6, 0, 0), To: dte(7, 0, 0)}, open, 0, 1); err == nil {
+		t.Errorf("NewDiscontinuousScale without open times fails")
+	}
+}
+
+func ExampleDiscontinuousScale() {
+	// working hours of the first week of 2024
+	cal, _ := ParseCalendar("days mon-fri\nhours 09:00-17:00")
+	week := MakeTimeSlice(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 7*Day)
+	open, _ := cal.WorkingSlices(week)
+
+	// a chart 1000 pixels wide, without nights nor week-end
+	scale, _ := NewDiscontinuousScale(week, open, 0, 1000)
+	fmt.Println(scale.Duration())
+	fmt.Println(scale.Position(time.Date(2024, 1, 3, 9, 0, 0, 0, time.UTC)))
+	fmt.Println(scale.Time(500).Format(time.DateTime))
+
+	// Output:
+	// 40h0m0s
+	// 400
+	// 2024-01-03 13:00:00
+}
@@ -6,6 +6,8 @@ package timeline
 import (
 	"fmt"
 	"math"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -196,6 +198,63 @@ func (leftd Duration) FormatOrderOfMagnitude(maxorder uint) (str string) {
 	return str + dust
 }
 
+// ParseOrderOfMagnitude parses a duration formated by FormatOrderOfMagnitude, in the following format:
+//
+//	'[-][100Y][12M][31d][24h][60m][99s][~]'
+//
+// Components must be in this order, each one at most once. A trailing `~` is accepted and ignored, so
+// the parsed duration is the truncated one. Years, months and days are converted with the average Year, Month and Day values.
+// Returns an error if the duration overflows a time.Duration, about 292 years.
+//
+// Special cases:
+//
+//	"infinite" // returns an infinite duration
+//	"0" // returns a zero duration
+func ParseOrderOfMagnitude(str string) (Duration, error) {
+	switch str {
+	case "infinite":
+		return Duration{}, nil
+	case "0":
+		return NewDuration(0), nil
+	}
+
+	s := strings.TrimSuffix(str, "~")
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	if s == "" {
+		return Duration{}, fmt.Errorf("invalid order of magnitude duration: %q", str)
+	}
+
+	const components = "YMdhms"
+	units := [6]time.Duration{Year, Month, Day, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	next := 0
+	for s != "" {
+		i := strings.IndexAny(s, components)
+		if i <= 0 {
+			return Duration{}, fmt.Errorf("invalid order of magnitude duration: %q", str)
+		}
+		c := strings.IndexByte(components, s[i])
+		if c < next {
+			return Duration{}, fmt.Errorf("invalid order of magnitude duration: %q", str)
+		}
+		n, err := strconv.ParseUint(s[:i], 10, 32)
+		if err != nil {
+			return Duration{}, fmt.Errorf("invalid order of magnitude duration: %q", str)
+		}
+		if n > uint64(math.MaxInt64/units[c]) || time.Duration(n)*units[c] > math.MaxInt64-d {
+			return Duration{}, fmt.Errorf("out of range order of magnitude duration: %q", str)
+		}
+		d += time.Duration(n) * units[c]
+		next = c + 1
+		s = s[i+1:]
+	}
+	if neg {
+		d = -d
+	}
+	return NewDuration(d), nil
+}
+
 // Default formating
 func (d Duration) String() string {
 	return d.FormatOrderOfMagnitude(3)
@@ -4,6 +4,7 @@
 package timeline
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -32,3 +33,99 @@ func TestDuration2(t *testing.T) {
 		t.Errorf("Duration Fails: %v, %v, %v, %v", t1, t2, dur1, dur2)
 	}
 }
+
+func TestParseOrderOfMagnitude(t *testing.T) {
+	cases := []struct {
+		str  string
+		want time.Duration
+	}{
+		{"0", 0},
+		{"0s~", 0},
+		{"10s", 10 * time.Second},
+		{"1d25m", Day + 25*time.Minute},
+		{"1M4d2h~", Month + 4*Day + 2*time.Hour},
+		{"-3Y2M", -3*Year - 2*Month},
+	}
+	for _, c := range cases {
+		d, err := ParseOrderOfMagnitude(c.str)
+		if err != nil || !d.IsFinite || d.Duration != c.want {
+			t.Errorf("ParseOrderOfMagnitude %q fails: got %v %v", c.str, d.Duration, err)
+		}
+	}
+
+	d, err := ParseOrderOfMagnitude("infinite")
+	if err != nil || d.IsFinite {
+		t.Errorf("ParseOrderOfMagnitude infinite fails: got %v %v", d, err)
+	}
+
+	for _, str := range []string{"", "-", "~", "1", "d", "1x", "2h1d", "1h1h", "1.5h", "-1-h", "1 h", "infinity"} {
+		if _, err := ParseOrderOfMagnitude(str); err == nil {
+			t.Errorf("ParseOrderOfMagnitude %q fails: want an error", str)
+		}
+	}
+
+	// out of range
+	for _, str := range []string{"4000000000Y", "293Y", "292Y4M", "9223372037s", "106751d23h47m17s"} {
+		if _, err := ParseOrderOfMagnitude(str); err == nil {
+			t.Errorf("ParseOrderOfMagnitude %q fails: want an out of range error", str)
+		}
+	}
+}
+
+func FuzzOrderOfMagnitude(f *testing.F) {
+	f.Add(int64(0))
+	f.Add(int64(time.Millisecond))
+	f.Add(int64(10*time.Second + 75*time.Millisecond))
+	f.Add(int64(Day + 25*time.Minute))
+	f.Add(int64(Month + 4*Day + 2*time.Hour + 35*time.Minute + 25*time.Second))
+	f.Add(int64(-3*Year - 2*Month))
+	f.Add(int64(-100*Year - 11*Month - 30*Day - 23*time.Hour - 59*time.Minute - 59*time.Second))
+
+	f.Fuzz(func(t *testing.T, ns int64) {
+		d := NewDuration(time.Duration(ns))
+		for order := uint(1); order <= 6; order++ {
+			str := d.FormatOrderOfMagnitude(order)
+			parsed, err := ParseOrderOfMagnitude(str)
+			if err != nil {
+				t.Fatalf("ParseOrderOfMagnitude %q fails: %v", str, err)
+			}
+			if parsed.Duration.Abs() > d.Duration.Abs() || (parsed.Duration != 0 && (parsed.Duration < 0) != (d.Duration < 0)) {
+				t.Errorf("ParseOrderOfMagnitude %q fails: got %v from %v", str, parsed.Duration, d.Duration)
+			}
+
+			// formating back the parsed duration gives the same output without dust
+			want := strings.TrimSuffix(str, "~")
+			if parsed.Duration == 0 {
+				want = "0"
+			}
+			if got := parsed.FormatOrderOfMagnitude(order); got != want {
+				t.Errorf("FormatOrderOfMagnitude fails: want %q got %q from %v", want, got, d.Duration)
+			}
+			// without dust the round trip is exact, at the second level
+			if !strings.HasSuffix(str, "~") && parsed.Duration != d.Duration.Truncate(time.Second) {
+				t.Errorf("ParseOrderOfMagnitude %q fails: want %v got %v", str, d.Duration.Truncate(time.Second), parsed.Duration)
+			}
+		}
+	})
+}
+
+func FuzzParseOrderOfMagnitude(f *testing.F) {
+	f.Add("1Y2M3d4h5m6s")
+	f.Add("-292Y3M")
+	f.Add("292Y4M")
+	f.Add("4000000000Y")
+	f.Add("9223372036s")
+	f.Add("9223372037s")
+	f.Add("-106751d23h47m16s~")
+
+	f.Fuzz(func(t *testing.T, str string) {
+		d, err := ParseOrderOfMagnitude(str)
+		if err != nil || str == "infinite" {
+			return
+		}
+		// never wraps around
+		if neg := strings.HasPrefix(str, "-"); d.Duration != 0 && (d.Duration < 0) != neg {
+			t.Errorf("ParseOrderOfMagnitude %q overflows: got %v", str, d.Duration)
+		}
+	})
+}
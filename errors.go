@@ -0,0 +1,30 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors returned by the package, wrapped with some context. Check them with errors.Is.
+var (
+	// ErrInfinite is returned when an infinite boundary or an infinite duration can not be processed.
+	ErrInfinite = errors.New("infinite")
+
+	// ErrInvalidMask is returned when a mask can not be used for scanning.
+	ErrInvalidMask = errors.New("invalid mask")
+
+	// ErrNonPositiveStep is returned when a step duration is zero or negative.
+	ErrNonPositiveStep = errors.New("non-positive step")
+)
+
+// CheckMask returns an error wrapping ErrInvalidMask if mask can not be used for scanning, like a nil mask,
+// MASK_NONE, an unknown TimeMask value or a StepMask without a valid unit.
+func CheckMask(mask Mask) error {
+	if !isValidMask(mask) {
+		return fmt.Errorf("%w: %v", ErrInvalidMask, mask)
+	}
+	return nil
+}
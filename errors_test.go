@@ -0,0 +1,86 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestErrors(t *testing.T) {
+	dte := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, err := MakeTimeSlice(dte, Day).Split(0); !errors.Is(err, ErrNonPositiveStep) {
+		t.Errorf("Split fails: got %v", err)
+	}
+	if _, err := (TimeSlice{From: dte}).Split(time.Hour); !errors.Is(err, ErrInfinite) {
+		t.Errorf("Split fails: got %v", err)
+	}
+	if _, err := (Calendar{}).Split(MakeTimeSlice(dte, Day), -time.Hour); !errors.Is(err, ErrNonPositiveStep) {
+		t.Errorf("Calendar.Split fails: got %v", err)
+	}
+	if _, err := (Calendar{}).WorkingSlices(TimeSlice{To: dte}); !errors.Is(err, ErrInfinite) {
+		t.Errorf("WorkingSlices fails: got %v", err)
+	}
+	if _, err := NewTimeSlice(time.Time{}, Day); !errors.Is(err, ErrInfinite) {
+		t.Errorf("NewTimeSlice fails: got %v", err)
+	}
+	if ts, err := NewTimeSlice(dte, Day); err != nil || ts != MakeTimeSlice(dte, Day) {
+		t.Errorf("NewTimeSlice fails: got %v %v", ts, err)
+	}
+	if _, err := NewTimeSlicePeriod(time.Time{}, Period{Months: 1}); !errors.Is(err, ErrInfinite) {
+		t.Errorf("NewTimeSlicePeriod fails: got %v", err)
+	}
+
+	// invalid masks
+	for _, mask := range []Mask{nil, MASK_NONE, TimeMask(99), StepMask{}, StepMask{Unit: 99}} {
+		if err := CheckMask(mask); !errors.Is(err, ErrInvalidMask) {
+			t.Errorf("CheckMask %v fails: got %v", mask, err)
+		}
+		cursor := dte
+		if got := MakeTimeSlice(dte, Day).Scan(&cursor, mask, true); !got.IsZero() || !cursor.IsZero() {
+			t.Errorf("Scan %v fails: got %v", mask, got)
+		}
+		for range MakeTimeSlice(dte, Day).Times(mask, true) {
+			t.Errorf("Times %v fails", mask)
+		}
+		cursor = dte
+		if got, err := MakeTimeSlice(dte, Day).ScanErr(&cursor, mask, true); !errors.Is(err, ErrInvalidMask) || !got.IsZero() || !cursor.IsZero() {
+			t.Errorf("ScanErr %v fails: got %v %v", mask, got, err)
+		}
+		cursor = dte
+		if _, err := (Calendar{WorkingDays: [7]bool{true, true, true, true, true, true, true}}).ScanErr(MakeTimeSlice(dte, Day), &cursor, mask, true); !errors.Is(err, ErrInvalidMask) || !cursor.IsZero() {
+			t.Errorf("Calendar.ScanErr %v fails: got %v", mask, err)
+		}
+		if _, err := MakeTimeSlice(dte, Day).TimesErr(mask, true); !errors.Is(err, ErrInvalidMask) {
+			t.Errorf("TimesErr %v fails: got %v", mask, err)
+		}
+		if mask == nil {
+			continue
+		}
+		if got, match := mask.Apply(dte.Add(time.Minute)); !got.Equal(dte.Add(time.Minute)) || (match && mask != MASK_NONE) {
+			t.Errorf("Apply %v fails: got %v", mask, got)
+		}
+		if got := mask.Add(dte); !got.Equal(dte) {
+			t.Errorf("Add %v fails: got %v", mask, got)
+		}
+		if got := mask.Sub(dte); !got.Equal(dte) {
+			t.Errorf("Sub %v fails: got %v", mask, got)
+		}
+	}
+	for _, mask := range []Mask{MASK_MILLISECOND, MASK_YEAR, StepMask{Unit: UNIT_DAY, Multiple: 3}} {
+		if err := CheckMask(mask); err != nil {
+			t.Errorf("CheckMask %v fails: got %v", mask, err)
+		}
+		// a valid mask past the end is not an error
+		cursor := dte.AddDate(1, 0, 0)
+		if got, err := MakeTimeSlice(dte, time.Millisecond).ScanErr(&cursor, mask, false); err != nil || !got.IsZero() {
+			t.Errorf("ScanErr %v fails: got %v %v", mask, got, err)
+		}
+		if times, err := MakeTimeSlice(dte, Day).TimesErr(mask, true); err != nil || times == nil {
+			t.Errorf("TimesErr %v fails: got %v", mask, err)
+		}
+	}
+}
@@ -137,15 +137,15 @@ func ExampleTimeSlice_GetScanMask_one() {
 
 	// Output:
 	// best scan mask:       month <== Timeslice: { 20081031 21:00:00 UTC - 20090131 04:30:00 UTC : 3M }
-	// best scan mask:       month <== Timeslice: { 20081031 21:00:00 UTC - 20081128 06:27:00 UTC : 27d9h27m }
+	// best scan mask:        week <== Timeslice: { 20081031 21:00:00 UTC - 20081128 06:27:00 UTC : 27d9h27m }
 	// best scan mask:         day <== Timeslice: { 20081031 21:00:00 UTC - 20081109 02:14:06 UTC : 8d5h14m~ }
 	// best scan mask:    half-day <== Timeslice: { 20081031 21:00:00 UTC - 20081103 08:10:13 UTC : 2d11h10m~ }
 	// best scan mask:     4 hours <== Timeslice: { 20081031 21:00:00 UTC - 20081101 14:45:04 UTC : 17h45m4s }
 	// best scan mask:   half-hour <== Timeslice: { 20081031 21:00:00 UTC - 20081101 02:19:31 UTC : 5h19m31s }
-	// best scan mask:  15 minutes <== Timeslice: { 20081031 21:00:00 UTC - 22:35:51 : 1h35m51s }
-	// best scan mask:  15 minutes <== Timeslice: { 20081031 21:00:00 UTC - 21:28:45 : 28m45s }
-	// best scan mask:      minute <== Timeslice: { 20081031 21:00:00 UTC - 21:08:37 : 8m37s }
-	// best scan mask:      minute <== Timeslice: { 20081031 21:00:00 UTC - 21:02:35 : 2m35s }
+	// best scan mask:  15 minutes <== Timeslice: { 20081031 21:00:00 UTC - 22:35:51 UTC : 1h35m51s }
+	// best scan mask:  15 minutes <== Timeslice: { 20081031 21:00:00 UTC - 21:28:45 UTC : 28m45s }
+	// best scan mask:      minute <== Timeslice: { 20081031 21:00:00 UTC - 21:08:37 UTC : 8m37s }
+	// best scan mask:  15 seconds <== Timeslice: { 20081031 21:00:00 UTC - 21:02:35 UTC : 2m35s }
 }
 
 func ExampleTimeMask_GetTimeFormat_one() {
@@ -183,6 +183,14 @@ func ExampleTimeMask_GetTimeFormat_one() {
 	// with mask:       month, renders: Oct
 	// with mask:     quarter, renders: 2008 Oct
 	// with mask:        year, renders: 2008
+	// with mask:        week, renders: Thu 30
+	// with mask: millisecond, renders: 59.000
+	// with mask:100 milliseconds, renders: 59.000
+	// with mask:      second, renders: 21:12:59
+	// with mask:   5 seconds, renders: 21:12:59
+	// with mask:  10 seconds, renders: 21:12:59
+	// with mask:  15 seconds, renders: 21:12:59
+	// with mask:  30 seconds, renders: 21:12:59
 	// Next time t2=2008-11-30 21:12:59 CET
 	// Streamlined output for t2 renders: Nov, Sun 30, 21:12
 }
@@ -0,0 +1,356 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ISO 8601 layouts accepted by ParseISOInterval, a time without offset is in UTC.
+var isoLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// ParseISOInterval parses an ISO 8601 time interval into a timeslice. The following formats are accepted:
+//
+//	"2024-01-01T00:00:00Z/2024-01-02T00:00:00Z" // start and end
+//	"2024-01-01T00:00Z/P1M"                     // start and period
+//	"P1D/2024-01-02"                            // period and end
+//	"../2024-01-02T00:00:00+01:00"              // open start
+//	"2024-01-01T00:00:00Z/.."                   // open end
+//
+// An open boundary, ".." or empty, is an infinite boundary. Periods are calendar-aware, like Period does.
+// A time without offset is in UTC.
+func ParseISOInterval(str string) (ts TimeSlice, err error) {
+	strfrom, strto, found := strings.Cut(str, "/")
+	if !found {
+		return TimeSlice{}, fmt.Errorf("invalid ISO 8601 interval: %q", str)
+	}
+
+	var pfrom, pto *Period
+	if strings.HasPrefix(strfrom, "P") || strings.HasPrefix(strfrom, "-P") {
+		p, err := ParseISOPeriod(strfrom)
+		if err != nil {
+			return TimeSlice{}, err
+		}
+		pfrom = &p
+	} else if ts.From, err = parseISOTime(strfrom); err != nil {
+		return TimeSlice{}, err
+	}
+	if strings.HasPrefix(strto, "P") || strings.HasPrefix(strto, "-P") {
+		p, err := ParseISOPeriod(strto)
+		if err != nil {
+			return TimeSlice{}, err
+		}
+		pto = &p
+	} else if ts.To, err = parseISOTime(strto); err != nil {
+		return TimeSlice{}, err
+	}
+
+	switch {
+	case pfrom != nil && pto != nil:
+		return TimeSlice{}, fmt.Errorf("invalid ISO 8601 interval: %q", str)
+	case pfrom != nil:
+		if ts.To.IsZero() {
+			return TimeSlice{}, fmt.Errorf("invalid ISO 8601 interval: %q", str)
+		}
+		ts.From = pfrom.Negate().AddTo(ts.To)
+	case pto != nil:
+		if ts.From.IsZero() {
+			return TimeSlice{}, fmt.Errorf("invalid ISO 8601 interval: %q", str)
+		}
+		ts.To = pto.AddTo(ts.From)
+	}
+	return ts, nil
+}
+
+// parseISOTime parses an ISO 8601 time, returns a zero time for an open boundary
+func parseISOTime(str string) (time.Time, error) {
+	if str == ".." || str == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range isoLayouts {
+		if t, err := time.Parse(layout, str); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid ISO 8601 time: %q", str)
+}
+
+// FormatISO returns the timeslice formated as an ISO 8601 time interval "start/end",
+// with times in the RFC 3339 format and infinite boundaries as "..".
+func (ts TimeSlice) FormatISO() string {
+	strfrom, strto := "..", ".."
+	if !ts.From.IsZero() {
+		strfrom = ts.From.Format(time.RFC3339Nano)
+	}
+	if !ts.To.IsZero() {
+		strto = ts.To.Format(time.RFC3339Nano)
+	}
+	return strfrom + "/" + strto
+}
+
+// isoComponent is a number followed by its designator in an ISO 8601 duration
+type isoComponent struct {
+	value      string
+	designator byte
+	clock      bool // within the time part after the 'T'
+}
+
+// splitISODuration splits an ISO 8601 duration "[-]PnYnMnWnDTnHnMnS" into its components, and checks their order.
+func splitISODuration(str string) (neg bool, components []isoComponent, err error) {
+	s := str
+	if strings.HasPrefix(s, "-") {
+		neg = true
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") || len(s) < 2 || strings.HasSuffix(s, "T") {
+		return false, nil, fmt.Errorf("invalid ISO 8601 duration: %q", str)
+	}
+	s = s[1:]
+
+	const dateorder, clockorder = "YMWD", "HMS"
+	order := dateorder
+	clock := false
+	next := 0
+	for s != "" {
+		if s[0] == 'T' {
+			if clock {
+				return false, nil, fmt.Errorf("invalid ISO 8601 duration: %q", str)
+			}
+			clock, order, next = true, clockorder, 0
+			s = s[1:]
+			continue
+		}
+		i := strings.IndexAny(s, order)
+		if i <= 0 {
+			return false, nil, fmt.Errorf("invalid ISO 8601 duration: %q", str)
+		}
+		pos := strings.IndexByte(order, s[i])
+		if pos < next {
+			return false, nil, fmt.Errorf("invalid ISO 8601 duration: %q", str)
+		}
+		components = append(components, isoComponent{value: strings.Replace(s[:i], ",", ".", 1), designator: s[i], clock: clock})
+		next = pos + 1
+		s = s[i+1:]
+	}
+	return neg, components, nil
+}
+
+// ParseISOPeriod parses an ISO 8601 duration "PnYnMnWnDTnHnMnS" into a calendar-aware period.
+// Weeks are converted into 7 days. A leading minus sign negates the period.
+//
+// Only integer values are accepted. As an extension to ISO 8601, like java.time.Period does, components can be signed,
+// so a mixed-sign period formated like "P1M-1D" by Period.FormatISO is parsed back.
+func ParseISOPeriod(str string) (p Period, err error) {
+	neg, components, err := splitISODuration(str)
+	if err != nil {
+		return Period{}, err
+	}
+	for _, c := range components {
+		v, err := strconv.Atoi(c.value)
+		if errors.Is(err, strconv.ErrRange) {
+			return Period{}, fmt.Errorf("out of range ISO 8601 period: %q", str)
+		}
+		if err != nil {
+			return Period{}, fmt.Errorf("invalid ISO 8601 period: %q", str)
+		}
+		switch {
+		case c.designator == 'Y':
+			p.Years = v
+		case c.designator == 'M' && !c.clock:
+			p.Months = v
+		case c.designator == 'W':
+			p.Days += 7 * v
+		case c.designator == 'D':
+			p.Days += v
+		case c.designator == 'H':
+			p.Hours = v
+		case c.designator == 'M':
+			p.Minutes = v
+		case c.designator == 'S':
+			p.Seconds = v
+		}
+	}
+	if neg {
+		p = p.Negate()
+	}
+	return p, nil
+}
+
+// FormatISO returns the period formated as an ISO 8601 duration "PnYnMnDTnHnMnS", only non zero components are output.
+//
+// A zero period returns "PT0S". A period with only negative components is prefixed with a minus sign.
+// A mixed-sign period gets its negative components signed, like "P1M-1D", which ISO 8601 does not define but ParseISOPeriod accepts.
+func (p Period) FormatISO() string {
+	if p.IsZero() {
+		return "PT0S"
+	}
+	sign := ""
+	if p.Years <= 0 && p.Months <= 0 && p.Days <= 0 && p.Hours <= 0 && p.Minutes <= 0 && p.Seconds <= 0 {
+		sign = "-"
+		p = p.Negate()
+	}
+	str := sign + "P"
+	for _, c := range []struct {
+		v int
+		d string
+	}{{p.Years, "Y"}, {p.Months, "M"}, {p.Days, "D"}} {
+		if c.v != 0 {
+			str += strconv.Itoa(c.v) + c.d
+		}
+	}
+	if p.Hours != 0 || p.Minutes != 0 || p.Seconds != 0 {
+		str += "T"
+		for _, c := range []struct {
+			v int
+			d string
+		}{{p.Hours, "H"}, {p.Minutes, "M"}, {p.Seconds, "S"}} {
+			if c.v != 0 {
+				str += strconv.Itoa(c.v) + c.d
+			}
+		}
+	}
+	return str
+}
+
+// ParseISODuration parses an ISO 8601 duration "PnYnMnWnDTnHnMnS" into a Duration.
+// Years and months are converted with the average Year and Month values, weeks are 7 days and days are 24 hours.
+// Decimal values are accepted, with a dot or a comma. A leading minus sign negates the duration.
+//
+// "infinite" returns an infinite duration.
+func ParseISODuration(str string) (Duration, error) {
+	if str == "infinite" {
+		return Duration{}, nil
+	}
+	neg, components, err := splitISODuration(str)
+	if err != nil {
+		return Duration{}, err
+	}
+	var d time.Duration
+	for _, c := range components {
+		if !isDecimal(c.value) {
+			return Duration{}, fmt.Errorf("invalid ISO 8601 duration: %q", str)
+		}
+		var unit time.Duration
+		switch {
+		case c.designator == 'Y':
+			unit = Year
+		case c.designator == 'M' && !c.clock:
+			unit = Month
+		case c.designator == 'W':
+			unit = Week
+		case c.designator == 'D':
+			unit = Day
+		case c.designator == 'H':
+			unit = time.Hour
+		case c.designator == 'M':
+			unit = time.Minute
+		case c.designator == 'S':
+			unit = time.Second
+		}
+		cd, ok := isoValue(c.value, unit)
+		if !ok || cd > math.MaxInt64-d {
+			return Duration{}, fmt.Errorf("out of range ISO 8601 duration: %q", str)
+		}
+		d += cd
+	}
+	if neg {
+		d = -d
+	}
+	return NewDuration(d), nil
+}
+
+// isDecimal returns true if value is an unsigned decimal number like "12", "1.5" or ".5", with at least one digit
+func isDecimal(value string) bool {
+	intpart, frac, _ := strings.Cut(value, ".")
+	digits := intpart + frac
+	return digits != "" && strings.Trim(digits, "0123456789") == ""
+}
+
+// isoValue returns the duration of a decimal value of unit, computed with integers so decimal seconds are exact up to the nanosecond.
+// Decimals beyond the billionth of the unit are rounded.
+//
+// returns false if the duration overflows.
+func isoValue(value string, unit time.Duration) (d time.Duration, ok bool) {
+	intpart, frac, _ := strings.Cut(value, ".")
+	if intpart != "" {
+		i, err := strconv.ParseInt(intpart, 10, 64)
+		if err != nil || i > int64(math.MaxInt64/unit) {
+			return 0, false
+		}
+		d = time.Duration(i) * unit
+	}
+	if frac != "" {
+		// the fraction as a number of billionths of the unit, all units are whole seconds
+		billionths, _ := strconv.ParseInt((frac + "000000000")[:9], 10, 64)
+		if len(frac) > 9 && frac[9] >= '5' {
+			billionths++
+		}
+		f := time.Duration(billionths) * (unit / time.Second)
+		if f > math.MaxInt64-d {
+			return 0, false
+		}
+		d += f
+	}
+	return d, true
+}
+
+// FormatISO returns the exact duration formated as an ISO 8601 duration "PnDTnHnMnS", where a day is 24 hours.
+// Seconds can have decimals. Years and months are never output as they are not exact.
+//
+// Special cases:
+//
+//	infinite duration // returns "infinite"
+//	zero duration // returns "PT0S"
+//	negative duration // returns a string started with a minus symbol
+func (d Duration) FormatISO() string {
+	if !d.IsFinite {
+		return "infinite"
+	}
+	if d.Duration == 0 {
+		return "PT0S"
+	}
+	str := "P"
+	left := d.Duration
+	if left < 0 {
+		str = "-P"
+		left = -left
+	}
+	days := left / Day
+	left -= days * Day
+	if days > 0 {
+		str += strconv.FormatInt(int64(days), 10) + "D"
+	}
+	if left > 0 {
+		str += "T"
+		hours := left / time.Hour
+		left -= hours * time.Hour
+		minutes := left / time.Minute
+		left -= minutes * time.Minute
+		if hours > 0 {
+			str += strconv.FormatInt(int64(hours), 10) + "H"
+		}
+		if minutes > 0 {
+			str += strconv.FormatInt(int64(minutes), 10) + "M"
+		}
+		if left > 0 {
+			secs := strconv.FormatInt(int64(left/time.Second), 10)
+			if ns := left % time.Second; ns > 0 {
+				secs += strings.TrimRight(fmt.Sprintf(".%09d", ns), "0")
+			}
+			str += secs + "S"
+		}
+	}
+	return str
+}
@@ -0,0 +1,217 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseISOInterval(t *testing.T) {
+	jan1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	jan2 := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		str  string
+		want TimeSlice
+	}{
+		{"2024-01-01T00:00:00Z/2024-01-02T00:00:00Z", TimeSlice{From: jan1, To: jan2}},
+		{"2024-01-01T00:00Z/P1M", TimeSlice{From: jan1, To: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)}},
+		{"2024-01-01/PT36H", TimeSlice{From: jan1, To: time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)}},
+		{"P1D/2024-01-02", TimeSlice{From: jan1, To: jan2}},
+		{"P1W/2024-01-08T00:00", TimeSlice{From: jan1, To: time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)}},
+		{"../2024-01-02T01:00:00+01:00", TimeSlice{To: jan2}},
+		{"2024-01-01T00:00:00.5Z/..", TimeSlice{From: jan1.Add(500 * time.Millisecond)}},
+		{"/2024-01-02T00:00:00Z", TimeSlice{To: jan2}},
+		{"../..", TimeSlice{}},
+	}
+	for _, c := range cases {
+		got, err := ParseISOInterval(c.str)
+		if err != nil || !got.From.Equal(c.want.From) || !got.To.Equal(c.want.To) {
+			t.Errorf("ParseISOInterval %q fails: want %v got %v %v", c.str, c.want, got, err)
+		}
+	}
+
+	for _, str := range []string{"", "2024-01-01", "P1D/P1M", "../P1D", "P1D/..", "2024-01-01/P1.5D", "2024-13-01/..", "2024-01-01/1D"} {
+		if _, err := ParseISOInterval(str); err == nil {
+			t.Errorf("ParseISOInterval %q fails: want an error", str)
+		}
+	}
+}
+
+func TestFormatISOInterval(t *testing.T) {
+	paris, _ := time.LoadLocation("Europe/Paris")
+	cases := []struct {
+		ts   TimeSlice
+		want string
+	}{
+		{TimeSlice{From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2024, 1, 2, 0, 0, 0, 0, paris)}, "2024-01-01T00:00:00Z/2024-01-02T00:00:00+01:00"},
+		{TimeSlice{From: time.Date(2024, 1, 1, 0, 0, 0, 1500, time.UTC)}, "2024-01-01T00:00:00.0000015Z/.."},
+		{TimeSlice{}, "../.."},
+	}
+	for _, c := range cases {
+		got := c.ts.FormatISO()
+		if got != c.want {
+			t.Errorf("FormatISO fails: want %q got %q", c.want, got)
+		}
+		if back, err := ParseISOInterval(got); err != nil || !back.From.Equal(c.ts.From) || !back.To.Equal(c.ts.To) {
+			t.Errorf("FormatISO round trip %q fails: got %v %v", got, back, err)
+		}
+	}
+}
+
+func TestISODuration(t *testing.T) {
+	cases := []struct {
+		str  string
+		want time.Duration
+	}{
+		{"PT0S", 0},
+		{"P1D", Day},
+		{"P2W", 2 * Week},
+		{"PT1H30M", 90 * time.Minute},
+		{"PT1.5H", 90 * time.Minute},
+		{"PT0,25S", 250 * time.Millisecond},
+		{"PT8.063976202S", 8063976202 * time.Nanosecond},
+		{"PT0.0000000015S", 2 * time.Nanosecond},
+		{"P0.5Y", Year / 2},
+		{"P1Y2M", Year + 2*Month},
+		{"-P1DT2M", -(Day + 2*time.Minute)},
+	}
+	for _, c := range cases {
+		if got, err := ParseISODuration(c.str); err != nil || got.Duration != c.want || !got.IsFinite {
+			t.Errorf("ParseISODuration %q fails: want %v got %v %v", c.str, c.want, got, err)
+		}
+	}
+	if got, err := ParseISODuration("infinite"); err != nil || got.IsFinite {
+		t.Errorf("ParseISODuration infinite fails: got %v %v", got, err)
+	}
+	for _, str := range []string{"", "P", "PT", "1D", "P1H", "PT1D", "P1D1Y", "PT1M1H", "P1DT", "P-1D", "P1e3D", "P1DT1HT1M",
+		"PNaND", "PTNaNS", "PInfD", "P1_0D", "PT.S", "PT1.2.3S"} {
+		if _, err := ParseISODuration(str); err == nil || !strings.HasPrefix(err.Error(), "invalid") {
+			t.Errorf("ParseISODuration %q fails: want a syntax error got %v", str, err)
+		}
+	}
+	for _, str := range []string{"P99999999999999999999D", "PT9223372037S", "P293Y", "P106751DT23H47M16.854775808S"} {
+		if _, err := ParseISODuration(str); err == nil || !strings.HasPrefix(err.Error(), "out of range") {
+			t.Errorf("ParseISODuration %q fails: want an out of range error got %v", str, err)
+		}
+	}
+
+	for _, c := range []struct {
+		d    Duration
+		want string
+	}{
+		{NewDuration(0), "PT0S"},
+		{NewDuration(Day + 90*time.Minute), "P1DT1H30M"},
+		{NewDuration(-(2*Day + 1500*time.Millisecond)), "-P2DT1.5S"},
+		{NewDuration(time.Millisecond), "PT0.001S"},
+		{Duration{}, "infinite"},
+	} {
+		got := c.d.FormatISO()
+		if got != c.want {
+			t.Errorf("FormatISO %v fails: want %q got %q", c.d, c.want, got)
+		}
+		if back, err := ParseISODuration(got); err != nil || back != c.d {
+			t.Errorf("FormatISO round trip %q fails: got %v %v", got, back, err)
+		}
+	}
+}
+
+func TestISOPeriod(t *testing.T) {
+	for _, c := range []struct {
+		str  string
+		want Period
+	}{
+		{"P1Y2M3DT4H5M6S", Period{Years: 1, Months: 2, Days: 3, Hours: 4, Minutes: 5, Seconds: 6}},
+		{"P1W2D", Period{Days: 9}},
+		{"PT1M", Period{Minutes: 1}},
+		{"-P1M", Period{Months: -1}},
+	} {
+		got, err := ParseISOPeriod(c.str)
+		if err != nil || got != c.want {
+			t.Errorf("ParseISOPeriod %q fails: want %v got %v %v", c.str, c.want, got, err)
+		}
+	}
+	for _, str := range []string{"PT1.5H", "PNaND", "PInfY", "P1_0D"} {
+		if _, err := ParseISOPeriod(str); err == nil || !strings.HasPrefix(err.Error(), "invalid") {
+			t.Errorf("ParseISOPeriod %q fails: want a syntax error got %v", str, err)
+		}
+	}
+	if _, err := ParseISOPeriod("P99999999999999999999D"); err == nil || !strings.HasPrefix(err.Error(), "out of range") {
+		t.Errorf("ParseISOPeriod fails: want an out of range error got %v", err)
+	}
+
+	for _, c := range []struct {
+		p    Period
+		want string
+	}{
+		{Period{}, "PT0S"},
+		{Period{Years: 1, Days: 3, Minutes: 5}, "P1Y3DT5M"},
+		{Period{Months: -1, Hours: -2}, "-P1MT2H"},
+		{Period{Months: 1, Days: -1}, "P1M-1D"},
+		{Period{Years: -1, Months: 2, Seconds: -3}, "P-1Y2MT-3S"},
+	} {
+		got := c.p.FormatISO()
+		if got != c.want {
+			t.Errorf("FormatISO %v fails: want %q got %q", c.p, c.want, got)
+		}
+		if back, err := ParseISOPeriod(got); err != nil || back != c.p {
+			t.Errorf("FormatISO round trip %q fails: got %v %v", got, back, err)
+		}
+	}
+}
+
+func FuzzISODuration(f *testing.F) {
+	f.Add(int64(0))
+	f.Add(int64(8063976202))
+	f.Add(int64(-(2*Day + 1500*time.Millisecond)))
+	f.Add(int64(math.MaxInt64))
+	f.Add(int64(math.MinInt64 + 1))
+
+	f.Fuzz(func(t *testing.T, ns int64) {
+		if ns == math.MinInt64 {
+			// its absolute value overflows
+			return
+		}
+		d := NewDuration(time.Duration(ns))
+		str := d.FormatISO()
+		if back, err := ParseISODuration(str); err != nil || back != d {
+			t.Errorf("FormatISO round trip %q fails: want %v got %v %v", str, time.Duration(ns), back.Duration, err)
+		}
+	})
+}
+
+func FuzzParseISODuration(f *testing.F) {
+	for _, str := range []string{"P1DT2H", "-PT1.5S", "PNaND", "P99999999999999999999D", "PT8.063976202S", "P1Y2M3W4DT5H6M7,8S"} {
+		f.Add(str)
+	}
+
+	f.Fuzz(func(t *testing.T, str string) {
+		d, err := ParseISODuration(str)
+		if err != nil {
+			// only numbers can be out of range
+			if strings.HasPrefix(err.Error(), "out of range") && strings.Trim(str, "-PTYMWDHS0123456789.,") != "" {
+				t.Errorf("ParseISODuration %q fails: want a syntax error got %v", str, err)
+			}
+			return
+		}
+		if back, err := ParseISODuration(d.FormatISO()); err != nil || back != d {
+			t.Errorf("ParseISODuration %q round trip fails: want %v got %v %v", str, d, back, err)
+		}
+	})
+}
+
+func ExampleParseISOInterval() {
+	for _, str := range []string{"2024-01-31T00:00Z/P1M", "P1D/2024-03-01T00:00:00Z", "2024-01-01T00:00:00Z/.."} {
+		ts, _ := ParseISOInterval(str)
+		fmt.Println(ts.FormatISO())
+	}
+
+	// Output:
+	// 2024-01-31T00:00:00Z/2024-03-02T00:00:00Z
+	// 2024-02-29T00:00:00Z/2024-03-01T00:00:00Z
+	// 2024-01-01T00:00:00Z/..
+}
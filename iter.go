@@ -0,0 +1,89 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"iter"
+	"time"
+)
+
+// Times returns an iterator over the times within the timeslice boundaries matching mask, like successive calls to Scan do.
+//
+// Use fBoundaries if you want the iterator to yield the boundaries even if they do not match the mask.
+//
+// Yields nothing if the begining is infinite or if mask can not be used for scanning, use TimesErr to get an error in this case.
+// If the end is infinite the iterator never ends by itself, so the caller must break the loop.
+func (ts TimeSlice) Times(mask Mask, fBoundaries bool) iter.Seq[time.Time] {
+	return func(yield func(time.Time) bool) {
+		var cursor time.Time
+		for ts.Scan(&cursor, mask, fBoundaries); !cursor.IsZero(); ts.Scan(&cursor, mask, fBoundaries) {
+			if !yield(cursor) {
+				return
+			}
+		}
+	}
+}
+
+// TimesErr returns the iterator returned by Times, or an error wrapping ErrInvalidMask if mask can not be used for scanning, see CheckMask.
+func (ts TimeSlice) TimesErr(mask Mask, fBoundaries bool) (iter.Seq[time.Time], error) {
+	if err := CheckMask(mask); err != nil {
+		return nil, err
+	}
+	return ts.Times(mask, fBoundaries), nil
+}
+
+// IndexedTimes returns an iterator over the times like Times does, with their index starting at 0.
+func (ts TimeSlice) IndexedTimes(mask Mask, fBoundaries bool) iter.Seq2[int, time.Time] {
+	return func(yield func(int, time.Time) bool) {
+		i := 0
+		for t := range ts.Times(mask, fBoundaries) {
+			if !yield(i, t) {
+				return
+			}
+			i++
+		}
+	}
+}
+
+// Chunks returns an iterator over the successive sub-timeslices of d duration, following the timeslice direction,
+// like Split does but lazily. The last chunk duration can be lower than d if the timeslice duration is not a multiple of d.
+//
+// Yields nothing if the begining is infinite or if d <= 0. If the end is infinite the iterator never ends by itself, so the caller must break the loop.
+func (ts TimeSlice) Chunks(d time.Duration) iter.Seq[TimeSlice] {
+	return func(yield func(TimeSlice) bool) {
+		if d <= 0 || ts.From.IsZero() {
+			return
+		}
+		if ts.Direction() == AntiChronological {
+			d = -d
+		}
+		for {
+			chunk := MakeTimeSlice(ts.From, d)
+			if !ts.To.IsZero() && (d > 0 && !chunk.To.Before(ts.To) || d < 0 && !chunk.To.After(ts.To)) {
+				chunk.To = ts.To
+				if !chunk.From.Equal(chunk.To) {
+					yield(chunk)
+				}
+				return
+			}
+			if !yield(chunk) {
+				return
+			}
+			ts.From = chunk.To
+		}
+	}
+}
+
+// IndexedChunks returns an iterator over the chunks like Chunks does, with their index starting at 0.
+func (ts TimeSlice) IndexedChunks(d time.Duration) iter.Seq2[int, TimeSlice] {
+	return func(yield func(int, TimeSlice) bool) {
+		i := 0
+		for chunk := range ts.Chunks(d) {
+			if !yield(i, chunk) {
+				return
+			}
+			i++
+		}
+	}
+}
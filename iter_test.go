@@ -0,0 +1,102 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestTimes(t *testing.T) {
+	ts := TimeSlice{From: time.Date(2024, 1, 1, 10, 20, 0, 0, time.UTC), To: time.Date(2024, 1, 1, 13, 10, 0, 0, time.UTC)}
+
+	// same times as Scan
+	for _, fb := range []bool{false, true} {
+		var want []time.Time
+		var cursor time.Time
+		for ts.Scan(&cursor, MASK_HOUR, fb); !cursor.IsZero(); ts.Scan(&cursor, MASK_HOUR, fb) {
+			want = append(want, cursor)
+		}
+		var got []time.Time
+		for tm := range ts.Times(MASK_HOUR, fb) {
+			got = append(got, tm)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("Times fboundaries=%v fails: want %v got %v", fb, want, got)
+		}
+	}
+
+	// infinite end, break after 50 times
+	inf := TimeSlice{From: ts.From}
+	n := 0
+	for i, tm := range inf.IndexedTimes(MASK_DAY, false) {
+		if i != n || !tm.Equal(time.Date(2024, 1, 2+i, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("IndexedTimes infinite fails at %d: got %v", i, tm)
+		}
+		if n++; n == 50 {
+			break
+		}
+	}
+	if n != 50 {
+		t.Errorf("IndexedTimes infinite fails: got %d times", n)
+	}
+
+	// infinite begining
+	for tm := range (TimeSlice{To: ts.To}).Times(MASK_HOUR, true) {
+		t.Errorf("Times infinite begining fails: got %v", tm)
+	}
+}
+
+func TestChunks(t *testing.T) {
+	ts := TimeSlice{From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)}
+
+	// same chunks as Split
+	for _, tsc := range []TimeSlice{ts, {From: ts.From, To: ts.To.Add(time.Minute)}, {From: ts.To, To: ts.From}, {From: ts.From, To: ts.From}} {
+		for _, d := range []time.Duration{time.Hour, 3 * time.Hour, 11 * time.Hour} {
+			want, _ := tsc.Split(d)
+			var got []TimeSlice
+			for i, chunk := range tsc.IndexedChunks(d) {
+				if i != len(got) {
+					t.Errorf("IndexedChunks fails: bad index %d", i)
+				}
+				got = append(got, chunk)
+			}
+			if len(got) != len(want) || fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("Chunks %v %v fails: want %v got %v", tsc, d, want, got)
+			}
+		}
+	}
+
+	// infinite end
+	n := 0
+	for chunk := range (TimeSlice{From: ts.From}).Chunks(Day) {
+		if !chunk.From.Equal(ts.From.Add(time.Duration(n)*Day)) || chunk.Duration().Duration != Day {
+			t.Errorf("Chunks infinite fails: got %v", chunk)
+		}
+		if n++; n == 1000 {
+			break
+		}
+	}
+
+	for chunk := range ts.Chunks(0) {
+		t.Errorf("Chunks zero duration fails: got %v", chunk)
+	}
+}
+
+func ExampleTimeSlice_Times() {
+	ts := TimeSlice{From: time.Date(2024, 1, 1, 10, 20, 0, 0, time.UTC)}
+	for i, t := range ts.IndexedTimes(MASK_HALFHOUR, true) {
+		if i == 4 {
+			break
+		}
+		fmt.Println(t.Format("15:04"))
+	}
+
+	// Output:
+	// 10:20
+	// 10:30
+	// 11:00
+	// 11:30
+}
@@ -0,0 +1,283 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// binary encoding version
+const binaryVersion byte = 1
+
+// jsonTimeSlice is the JSON representation of a TimeSlice, infinite boundaries are omitted
+type jsonTimeSlice struct {
+	From *time.Time `json:"from,omitempty"`
+	To   *time.Time `json:"to,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+// The timeslice is encoded as an object like {"from":"2024-01-01T00:00:00Z","to":"2024-01-02T00:00:00Z"},
+// with times in the RFC 3339 format. Infinite boundaries are omitted.
+func (ts TimeSlice) MarshalJSON() ([]byte, error) {
+	var jts jsonTimeSlice
+	if !ts.From.IsZero() {
+		jts.From = &ts.From
+	}
+	if !ts.To.IsZero() {
+		jts.To = &ts.To
+	}
+	return json.Marshal(jts)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// Accepts an object like {"from":"2024-01-01T00:00:00Z","to":"2024-01-02T00:00:00Z"} where null or missing boundaries are infinite,
+// or a string with an ISO 8601 time interval.
+func (pts *TimeSlice) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return err
+		}
+		return pts.UnmarshalText([]byte(str))
+	}
+	var jts jsonTimeSlice
+	if err := json.Unmarshal(data, &jts); err != nil {
+		return err
+	}
+	*pts = TimeSlice{}
+	if jts.From != nil {
+		pts.From = *jts.From
+	}
+	if jts.To != nil {
+		pts.To = *jts.To
+	}
+	return nil
+}
+
+// MarshalText implements the encoding.TextMarshaler interface. The timeslice is encoded as an ISO 8601 time interval, see FormatISO.
+func (ts TimeSlice) MarshalText() ([]byte, error) {
+	return []byte(ts.FormatISO()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface. The text must be an ISO 8601 time interval, see ParseISOInterval.
+func (pts *TimeSlice) UnmarshalText(text []byte) error {
+	ts, err := ParseISOInterval(string(text))
+	if err != nil {
+		return err
+	}
+	*pts = ts
+	return nil
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+// Each boundary is encoded with time.MarshalBinary, infinite boundaries are not encoded.
+func (ts TimeSlice) MarshalBinary() ([]byte, error) {
+	var flags byte
+	buf := []byte{binaryVersion, 0}
+	for i, t := range []time.Time{ts.From, ts.To} {
+		if t.IsZero() {
+			continue
+		}
+		flags |= 1 << i
+		bt, err := t.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, byte(len(bt)))
+		buf = append(buf, bt...)
+	}
+	buf[1] = flags
+	return buf, nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+func (pts *TimeSlice) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 || data[0] != binaryVersion || data[1] > 3 {
+		return errors.New("TimeSlice.UnmarshalBinary: invalid data")
+	}
+	var ts TimeSlice
+	flags := data[1]
+	data = data[2:]
+	for i, pt := range []*time.Time{&ts.From, &ts.To} {
+		if flags&(1<<i) == 0 {
+			continue
+		}
+		if len(data) < 1 || len(data) < 1+int(data[0]) {
+			return errors.New("TimeSlice.UnmarshalBinary: invalid length")
+		}
+		if err := pt.UnmarshalBinary(data[1 : 1+int(data[0])]); err != nil {
+			return err
+		}
+		data = data[1+int(data[0]):]
+	}
+	if len(data) != 0 {
+		return errors.New("TimeSlice.UnmarshalBinary: invalid length")
+	}
+	*pts = ts
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+// The duration is encoded as an ISO 8601 duration string like "P1DT2H", see FormatISO. An infinite duration is encoded as null.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	if !d.IsFinite {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.FormatISO())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// Accepts null for an infinite duration, a string like UnmarshalText does, or a number of nanoseconds.
+// The former object encoding {"Duration":n,"IsFinite":b} is also accepted.
+func (pd *Duration) UnmarshalJSON(data []byte) error {
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		*pd = Duration{}
+		return nil
+	case bytes.HasPrefix(data, []byte(`"`)):
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return err
+		}
+		return pd.UnmarshalText([]byte(str))
+	case bytes.HasPrefix(data, []byte("{")):
+		var former struct {
+			Duration time.Duration
+			IsFinite bool
+		}
+		if err := json.Unmarshal(data, &former); err != nil {
+			return err
+		}
+		*pd = Duration{Duration: former.Duration, IsFinite: former.IsFinite}
+		return nil
+	}
+	var ns int64
+	if err := json.Unmarshal(data, &ns); err != nil {
+		return fmt.Errorf("invalid duration: %s", data)
+	}
+	*pd = NewDuration(time.Duration(ns))
+	return nil
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+// The duration is encoded as an ISO 8601 duration like "P1DT2H", or "infinite", see FormatISO.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.FormatISO()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+// Accepts an ISO 8601 duration like "P1DT2H", see ParseISODuration, or an order of magnitude like "1d2h", see ParseOrderOfMagnitude.
+// "infinite" returns an infinite duration.
+func (pd *Duration) UnmarshalText(text []byte) (err error) {
+	str := string(text)
+	var d Duration
+	if strings.HasPrefix(str, "P") || strings.HasPrefix(str, "-P") {
+		d, err = ParseISODuration(str)
+	} else {
+		d, err = ParseOrderOfMagnitude(str)
+	}
+	if err != nil {
+		return err
+	}
+	*pd = d
+	return nil
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+func (d Duration) MarshalBinary() ([]byte, error) {
+	buf := []byte{binaryVersion, 0}
+	if d.IsFinite {
+		buf[1] = 1
+	}
+	return binary.BigEndian.AppendUint64(buf, uint64(d.Duration)), nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+func (pd *Duration) UnmarshalBinary(data []byte) error {
+	if len(data) != 10 || data[0] != binaryVersion || data[1] > 1 {
+		return errors.New("Duration.UnmarshalBinary: invalid data")
+	}
+	*pd = Duration{Duration: time.Duration(binary.BigEndian.Uint64(data[2:])), IsFinite: data[1] == 1}
+	return nil
+}
+
+// ParseTimeMask returns the mask corresponding to its name, as returned by String, like "half-hour".
+func ParseTimeMask(name string) (TimeMask, error) {
+	for mask := MASK_NONE; mask <= MASK_max; mask++ {
+		if mask.String() == name {
+			return mask, nil
+		}
+	}
+	return MASK_NONE, fmt.Errorf("invalid mask: %q", name)
+}
+
+// MarshalText implements the encoding.TextMarshaler interface. The mask is encoded by its name, like "half-hour".
+func (mask TimeMask) MarshalText() ([]byte, error) {
+	if mask < MASK_NONE || mask > MASK_max {
+		return nil, fmt.Errorf("invalid mask: %d", mask)
+	}
+	return []byte(mask.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface, see ParseTimeMask.
+func (pmask *TimeMask) UnmarshalText(text []byte) error {
+	mask, err := ParseTimeMask(string(text))
+	if err != nil {
+		return err
+	}
+	*pmask = mask
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface. The mask is encoded by its name, like "half-hour".
+func (mask TimeMask) MarshalJSON() ([]byte, error) {
+	text, err := mask.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// Accepts the mask name, or the former encoding as a number, prior to v2.6.0.
+func (pmask *TimeMask) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return err
+		}
+		return pmask.UnmarshalText([]byte(str))
+	}
+	var n int
+	// masks prior to v2.6.0 range from MASK_NONE to MASK_YEAR
+	if err := json.Unmarshal(data, &n); err != nil || n < int(MASK_NONE) || n > int(MASK_YEAR) {
+		return fmt.Errorf("invalid mask: %s", data)
+	}
+	*pmask = TimeMask(n)
+	return nil
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+// The mask is encoded by its value, which does not change across versions.
+func (mask TimeMask) MarshalBinary() ([]byte, error) {
+	if mask < MASK_NONE || mask > MASK_max {
+		return nil, fmt.Errorf("invalid mask: %d", mask)
+	}
+	return []byte{binaryVersion, byte(mask)}, nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+func (pmask *TimeMask) UnmarshalBinary(data []byte) error {
+	if len(data) != 2 || data[0] != binaryVersion || TimeMask(data[1]) > MASK_max {
+		return errors.New("TimeMask.UnmarshalBinary: invalid data")
+	}
+	*pmask = TimeMask(data[1])
+	return nil
+}
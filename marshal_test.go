@@ -0,0 +1,188 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMarshalTimeSlice(t *testing.T) {
+	paris, _ := time.LoadLocation("Europe/Paris")
+	jan1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	jan2 := time.Date(2024, 1, 2, 0, 0, 0, 0, paris)
+	cases := []struct {
+		ts       TimeSlice
+		wantjson string
+		wanttext string
+	}{
+		{TimeSlice{From: jan1, To: jan2}, `{"from":"2024-01-01T00:00:00Z","to":"2024-01-02T00:00:00+01:00"}`, "2024-01-01T00:00:00Z/2024-01-02T00:00:00+01:00"},
+		{TimeSlice{From: jan1}, `{"from":"2024-01-01T00:00:00Z"}`, "2024-01-01T00:00:00Z/.."},
+		{TimeSlice{To: jan2}, `{"to":"2024-01-02T00:00:00+01:00"}`, "../2024-01-02T00:00:00+01:00"},
+		{TimeSlice{}, `{}`, "../.."},
+	}
+	for _, c := range cases {
+		bjson, err := json.Marshal(c.ts)
+		if err != nil || string(bjson) != c.wantjson {
+			t.Errorf("MarshalJSON fails: want %s got %s %v", c.wantjson, bjson, err)
+		}
+		var back TimeSlice
+		if err := json.Unmarshal(bjson, &back); err != nil || !back.From.Equal(c.ts.From) || !back.To.Equal(c.ts.To) {
+			t.Errorf("UnmarshalJSON %s fails: got %v %v", bjson, back, err)
+		}
+
+		btext, err := c.ts.MarshalText()
+		if err != nil || string(btext) != c.wanttext {
+			t.Errorf("MarshalText fails: want %s got %s %v", c.wanttext, btext, err)
+		}
+		back = TimeSlice{}
+		if err := back.UnmarshalText(btext); err != nil || !back.From.Equal(c.ts.From) || !back.To.Equal(c.ts.To) {
+			t.Errorf("UnmarshalText %s fails: got %v %v", btext, back, err)
+		}
+
+		bbin, err := c.ts.MarshalBinary()
+		back = TimeSlice{From: jan1, To: jan1}
+		if err != nil || back.UnmarshalBinary(bbin) != nil || !back.From.Equal(c.ts.From) || !back.To.Equal(c.ts.To) {
+			t.Errorf("MarshalBinary %v fails: got %v %v", c.ts, back, err)
+		}
+	}
+
+	var ts TimeSlice
+	if err := json.Unmarshal([]byte(`{"from":null,"to":"2024-01-02T00:00:00Z"}`), &ts); err != nil || !ts.From.IsZero() || ts.To.IsZero() {
+		t.Errorf("UnmarshalJSON null fails: got %v %v", ts, err)
+	}
+	if err := json.Unmarshal([]byte(`"2024-01-01T00:00Z/P1D"`), &ts); err != nil || !ts.To.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("UnmarshalJSON ISO string fails: got %v %v", ts, err)
+	}
+	for _, data := range [][]byte{nil, {2, 0}, {1, 4}, {1, 1, 30, 1}, {1, 0, 0}} {
+		if err := ts.UnmarshalBinary(data); err == nil {
+			t.Errorf("UnmarshalBinary %v fails: want an error", data)
+		}
+	}
+}
+
+func TestMarshalDuration(t *testing.T) {
+	cases := []struct {
+		d        Duration
+		wantjson string
+		wanttext string
+	}{
+		{NewDuration(Day + 2*time.Hour), `"P1DT2H"`, "P1DT2H"},
+		{NewDuration(-1500 * time.Millisecond), `"-PT1.5S"`, "-PT1.5S"},
+		{NewDuration(0), `"PT0S"`, "PT0S"},
+		{NewDuration(8063976202 * time.Nanosecond), `"PT8.063976202S"`, "PT8.063976202S"},
+		{NewDuration(-(Day + 1)), `"-P1DT0.000000001S"`, "-P1DT0.000000001S"},
+		{Duration{}, `null`, "infinite"},
+	}
+	for _, c := range cases {
+		bjson, err := json.Marshal(c.d)
+		if err != nil || string(bjson) != c.wantjson {
+			t.Errorf("MarshalJSON fails: want %s got %s %v", c.wantjson, bjson, err)
+		}
+		back := NewDuration(time.Hour)
+		if err := json.Unmarshal(bjson, &back); err != nil || back != c.d {
+			t.Errorf("UnmarshalJSON %s fails: got %v %v", bjson, back, err)
+		}
+
+		btext, err := c.d.MarshalText()
+		if err != nil || string(btext) != c.wanttext {
+			t.Errorf("MarshalText fails: want %s got %s %v", c.wanttext, btext, err)
+		}
+		back = NewDuration(time.Hour)
+		if err := back.UnmarshalText(btext); err != nil || back != c.d {
+			t.Errorf("UnmarshalText %s fails: got %v %v", btext, back, err)
+		}
+
+		bbin, err := c.d.MarshalBinary()
+		back = NewDuration(time.Hour)
+		if err != nil || back.UnmarshalBinary(bbin) != nil || back != c.d {
+			t.Errorf("MarshalBinary %v fails: got %v %v", c.d, back, err)
+		}
+	}
+
+	for data, want := range map[string]Duration{
+		`"1d2h"`:        NewDuration(Day + 2*time.Hour),
+		`"infinite"`:    {},
+		`3600000000000`: NewDuration(time.Hour),
+		`{"Duration":3600000000000,"IsFinite":true}`: NewDuration(time.Hour),
+	} {
+		var d Duration
+		if err := json.Unmarshal([]byte(data), &d); err != nil || d != want {
+			t.Errorf("UnmarshalJSON %s fails: want %v got %v %v", data, want, d, err)
+		}
+	}
+	var d Duration
+	for _, data := range []string{`"1x"`, `true`, `"P1X"`} {
+		if err := json.Unmarshal([]byte(data), &d); err == nil {
+			t.Errorf("UnmarshalJSON %s fails: want an error", data)
+		}
+	}
+}
+
+func TestMarshalTimeMask(t *testing.T) {
+	for mask := MASK_NONE; mask <= MASK_max; mask++ {
+		bjson, err := json.Marshal(mask)
+		if err != nil || string(bjson) != `"`+mask.String()+`"` {
+			t.Errorf("MarshalJSON %v fails: got %s %v", mask, bjson, err)
+		}
+		var back TimeMask
+		if err := json.Unmarshal(bjson, &back); err != nil || back != mask {
+			t.Errorf("UnmarshalJSON %s fails: got %v %v", bjson, back, err)
+		}
+		bbin, _ := mask.MarshalBinary()
+		back = MASK_NONE
+		if err := back.UnmarshalBinary(bbin); err != nil || back != mask {
+			t.Errorf("UnmarshalBinary %v fails: got %v %v", bbin, back, err)
+		}
+	}
+
+	// binary encodings are stable across versions
+	for mask, want := range map[TimeMask][]byte{
+		MASK_MINUTE:      {binaryVersion, 1},
+		MASK_DAY:         {binaryVersion, 7},
+		MASK_YEAR:        {binaryVersion, 10},
+		MASK_WEEK:        {binaryVersion, 11},
+		MASK_MILLISECOND: {binaryVersion, 12},
+	} {
+		if got, err := mask.MarshalBinary(); err != nil || !bytes.Equal(got, want) {
+			t.Errorf("MarshalBinary %v fails: want %v got %v %v", mask, want, got, err)
+		}
+	}
+
+	var mask TimeMask
+	if err := json.Unmarshal([]byte(`3`), &mask); err != nil || mask != MASK_HALFHOUR {
+		t.Errorf("UnmarshalJSON number fails: got %v %v", mask, err)
+	}
+	if err := json.Unmarshal([]byte(`8`), &mask); err != nil || mask != MASK_MONTH {
+		t.Errorf("UnmarshalJSON number fails: got %v %v", mask, err)
+	}
+	if _, err := json.Marshal(TimeMask(99)); err == nil {
+		t.Error("MarshalJSON fails: want an error")
+	}
+	for _, data := range []string{`"weekly"`, `11`, `-1`} {
+		if err := json.Unmarshal([]byte(data), &mask); err == nil {
+			t.Errorf("UnmarshalJSON %s fails: want an error", data)
+		}
+	}
+}
+
+func ExampleTimeSlice_MarshalJSON() {
+	rec := struct {
+		Slot TimeSlice `json:"slot"`
+		Mask TimeMask  `json:"mask"`
+		Grid Duration  `json:"grid"`
+		Max  Duration  `json:"max"`
+	}{
+		Slot: TimeSlice{From: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)},
+		Mask: MASK_HALFHOUR,
+		Grid: NewDuration(30 * time.Minute),
+	}
+	b, _ := json.Marshal(rec)
+	fmt.Println(string(b))
+
+	// Output: {"slot":{"from":"2024-01-01T09:00:00Z"},"mask":"half-hour","grid":"PT30M","max":null}
+}
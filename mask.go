@@ -0,0 +1,402 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"fmt"
+	"time"
+)
+
+// Mask is used for scanning a TimeSlice and to get the time corresponding to a rounding period.
+// It's implemented by the predefined TimeMask values, and by StepMask for user-defined steps.
+type Mask interface {
+	// Apply the mask to a date and returns a masked time and a flag indicating if the given time matches exactly the mask
+	Apply(t time.Time) (masked time.Time, exactMatch bool)
+	// Add applies the mask and adds the mask increment to the given time
+	Add(t time.Time) time.Time
+	// Sub applies the mask and substitute the mask increment to the given time
+	Sub(t time.Time) time.Time
+	// GetTimeFormat returns the best appropriate and streamlined string time format, according to the mask
+	GetTimeFormat(newt time.Time, formert time.Time) string
+	// Format returns newt formated as a streamlined label, according to the mask
+	Format(newt time.Time, formert time.Time) string
+	// Duration returns the typical duration of a mask increment, with average months and years
+	Duration() time.Duration
+	String() string
+}
+
+// TimeUnit is the unit of a StepMask
+type TimeUnit int
+
+const (
+	UNIT_MILLISECOND TimeUnit = 1
+	UNIT_SECOND      TimeUnit = 2
+	UNIT_MINUTE      TimeUnit = 3
+	UNIT_HOUR        TimeUnit = 4
+	UNIT_DAY         TimeUnit = 5
+	UNIT_WEEK        TimeUnit = 6
+	UNIT_MONTH       TimeUnit = 7
+	UNIT_YEAR        TimeUnit = 8
+)
+
+func (unit TimeUnit) String() string {
+	switch unit {
+	case UNIT_MILLISECOND:
+		return "millisecond"
+	case UNIT_SECOND:
+		return "second"
+	case UNIT_MINUTE:
+		return "minute"
+	case UNIT_HOUR:
+		return "hour"
+	case UNIT_DAY:
+		return "day"
+	case UNIT_WEEK:
+		return "week"
+	case UNIT_MONTH:
+		return "month"
+	case UNIT_YEAR:
+		return "year"
+	}
+	return "?"
+}
+
+// Duration returns the typical duration of the unit, with average months and years
+func (unit TimeUnit) Duration() time.Duration {
+	switch unit {
+	case UNIT_MILLISECOND:
+		return time.Millisecond
+	case UNIT_SECOND:
+		return time.Second
+	case UNIT_MINUTE:
+		return time.Minute
+	case UNIT_HOUR:
+		return time.Hour
+	case UNIT_DAY:
+		return Day
+	case UNIT_WEEK:
+		return Week
+	case UNIT_MONTH:
+		return Month
+	case UNIT_YEAR:
+		return Year
+	}
+	return 0
+}
+
+// StepMask is a user-defined mask, stepping by a Multiple of a time Unit, like 250 milliseconds, 5 minutes, 2 hours, 10 days, 6 months or a decade.
+//
+// Without Anchor, steps are aligned on the o'clock periods of the upper unit, and restart at every upper period:
+// milliseconds within the second, seconds within the minute, minutes within the hour, hours within the day, days within the month (from the 1st), ISO weeks within the ISO week-year (from Monday of week 1),
+// months within the year (from January) and years from year 0.
+// So 7 minutes steps are 00, 07, ... 56, then 00 of the next hour.
+//
+// With an Anchor, steps are aligned on the anchor whatever the upper periods:
+//   - milliseconds, seconds, minutes and hours are exact durations from the anchor time.
+//   - days and weeks are calendar days from the anchor date, in the anchor location. So weeks start on the anchor weekday, see WeekMask.
+//   - months and years start on the first day of the anchor month, like a fiscal year starting in April.
+//
+// Steps are computed in the location of the given time, and follow its daylight saving time changes:
+//   - milliseconds, seconds, minutes and hours are o'clock times. So a skipped hour is not scanned, and a repeated hour is scanned twice, once per offset.
+//   - multiple hours, days and upper units follow the wall clock, so days always start at midnight, and a day can last 23 or 25 hours.
+//     A wall clock skipped by a change is moved forward by the length of the change, like 02:00 becomes 03:00, or midnight becomes 01:00
+//     in zones where clocks go forward at midnight. A wall clock repeated by a change gets its first occurrence.
+//
+// Anchored milliseconds, seconds, minutes and hours are exact durations and ignore daylight saving time changes.
+type StepMask struct {
+	Unit     TimeUnit
+	Multiple int       // number of units per step, 1 if zero or negative
+	Anchor   time.Time // optional origin of the steps
+}
+
+// Duration returns the typical duration of a step, with average months and years
+func (sm StepMask) Duration() time.Duration {
+	return time.Duration(sm.multiple()) * sm.Unit.Duration()
+}
+
+// String returns the step like "5 minutes" or "month"
+func (sm StepMask) String() string {
+	if sm.multiple() == 1 {
+		return sm.Unit.String()
+	}
+	return fmt.Sprintf("%d %ss", sm.multiple(), sm.Unit)
+}
+
+// WeekMask returns a week mask with weeks starting on the first weekday, like time.Sunday.
+// Use MASK_WEEK for ISO 8601 weeks starting on Monday.
+func WeekMask(first time.Weekday) StepMask {
+	// 2024-01-01 is a monday
+	return StepMask{Unit: UNIT_WEEK, Multiple: 1, Anchor: time.Date(2024, 1, 1+(int(first)+6)%7, 0, 0, 0, 0, time.UTC)}
+}
+
+// GetTimeFormat returns the best appropriate and streamlined string time format, according to the step.
+// Formats are the ones of the nearest predefined TimeMask, see TimeMask.GetTimeFormat.
+func (sm StepMask) GetTimeFormat(newt time.Time, formert time.Time) string {
+	return sm.nearest().GetTimeFormat(newt, formert)
+}
+
+// Format returns newt formated with GetTimeFormat. Weeks are labeled like "2024-W07", see TimeMask.Format.
+// A week which does not start on monday gets the ISO week containing most of its days.
+func (sm StepMask) Format(newt time.Time, formert time.Time) string {
+	if sm.Unit == UNIT_WEEK && sm.multiple() == 1 {
+		// the middle day of the week is in the ISO week containing most of its days
+		first, _ := sm.Apply(newt)
+		return MASK_WEEK.Format(first.AddDate(0, 0, 3), formert)
+	}
+	return sm.nearest().Format(newt, formert)
+}
+
+// Apply the step to a date and returns the begining of the step containing t, in the location of t,
+// and a flag indicating if the given time matches exactly the begining of the step.
+//
+// returns t unchanged and false if the unit is not an allowed value, see CheckMask.
+func (sm StepMask) Apply(t time.Time) (masked time.Time, exactMatch bool) {
+	if !isValidMask(sm) {
+		return t, false
+	}
+	n := sm.multiple()
+	Y, M, d := t.Date()
+	h, m := t.Hour(), t.Minute()
+	loc := t.Location()
+	s, ns := t.Second(), t.Nanosecond()
+	clock := time.Duration(s)*time.Second + time.Duration(ns)
+
+	if sm.Anchor.IsZero() {
+		switch {
+		case sm.Unit == UNIT_MILLISECOND:
+			masked = t.Add(-time.Duration(ns/1e6%n)*time.Millisecond - time.Duration(ns%1e6))
+		case sm.Unit == UNIT_SECOND:
+			masked = t.Add(-time.Duration(s%n)*time.Second - time.Duration(ns))
+		case sm.Unit == UNIT_MINUTE:
+			masked = t.Add(-time.Duration(m%n)*time.Minute - clock)
+		case sm.Unit == UNIT_HOUR && n == 1:
+			// o'clock with the offset of t, or the wall clock if the offset changes within the hour
+			masked = t.Add(-time.Duration(m)*time.Minute - clock)
+			if masked.Hour() != h || masked.Minute() != 0 {
+				masked = wallClock(Y, M, d, h, loc)
+			}
+		case sm.Unit == UNIT_HOUR:
+			masked = wallClock(Y, M, d, h/n*n, loc)
+		case sm.Unit == UNIT_DAY:
+			masked = wallClock(Y, M, (d-1)/n*n+1, 0, loc)
+		case sm.Unit == UNIT_WEEK:
+			monday := d - (int(t.Weekday())+6)%7
+			_, w := time.Date(Y, M, monday, 0, 0, 0, 0, time.UTC).ISOWeek()
+			masked = wallClock(Y, M, monday-7*((w-1)%n), 0, loc)
+		case sm.Unit == UNIT_MONTH:
+			masked = wallClock(Y, (M-1)/time.Month(n)*time.Month(n)+1, 1, 0, loc)
+		default: // UNIT_YEAR
+			masked = wallClock(floorDiv(Y, n)*n, 1, 1, 0, loc)
+		}
+		return masked, masked.Equal(t)
+	}
+
+	aY, aM, ad := sm.Anchor.Date()
+	switch sm.Unit {
+	case UNIT_MILLISECOND, UNIT_SECOND, UNIT_MINUTE, UNIT_HOUR:
+		step := time.Duration(n) * sm.Unit.Duration()
+		k := floorDiv64(int64(t.Sub(sm.Anchor)), int64(step))
+		masked = sm.Anchor.Add(time.Duration(k) * step).In(loc)
+	case UNIT_DAY, UNIT_WEEK:
+		if sm.Unit == UNIT_WEEK {
+			n *= 7
+		}
+		k := floorDiv(civilDays(Y, M, d)-civilDays(aY, aM, ad), n)
+		masked = wallClock(aY, aM, ad+k*n, 0, loc)
+	default: // UNIT_MONTH, UNIT_YEAR
+		if sm.Unit == UNIT_YEAR {
+			n *= 12
+		}
+		k := floorDiv(12*(Y-aY)+int(M-aM), n)
+		masked = wallClock(aY, aM+time.Month(k*n), 1, 0, loc)
+	}
+	return masked, masked.Equal(t)
+}
+
+// Add applies the step and adds the step increment to the given time.
+// Without Anchor, the result never goes over the begining of the next upper period.
+//
+// returns t unchanged if the unit is not an allowed value.
+func (sm StepMask) Add(t time.Time) time.Time {
+	if !isValidMask(sm) {
+		return t
+	}
+	t, _ = sm.Apply(t)
+	n := sm.multiple()
+	Y, M, d := t.Date()
+	loc := t.Location()
+
+	var next, upper time.Time
+	switch sm.Unit {
+	case UNIT_MILLISECOND:
+		next = t.Add(time.Duration(n) * time.Millisecond)
+		upper = t.Add(time.Second - time.Duration(t.Nanosecond()))
+	case UNIT_SECOND:
+		next = t.Add(time.Duration(n) * time.Second)
+		upper = t.Add(time.Duration(60-t.Second()) * time.Second)
+	case UNIT_MINUTE:
+		next = t.Add(time.Duration(n) * time.Minute)
+		upper = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+	case UNIT_HOUR:
+		switch {
+		case !sm.Anchor.IsZero():
+			next = t.Add(time.Duration(n) * time.Hour)
+		case n == 1:
+			// the next o'clock, an offset change can make it sooner or later than one hour
+			if next, _ = sm.Apply(t.Add(time.Hour)); !next.After(t) {
+				next, _ = sm.Apply(t.Add(2 * time.Hour))
+			}
+		default:
+			next = wallClock(Y, M, d, t.Hour()/n*n+n, loc)
+			upper = wallClock(Y, M, d+1, 0, loc)
+		}
+	case UNIT_DAY:
+		next = wallClock(Y, M, d+n, 0, loc)
+		upper = wallClock(Y, M+1, 1, 0, loc)
+	case UNIT_WEEK:
+		next = wallClock(Y, M, d+7*n, 0, loc)
+		// monday of the first ISO week of the next ISO week-year
+		isoY, _ := t.ISOWeek()
+		jan4 := time.Date(isoY+1, 1, 4, 0, 0, 0, 0, time.UTC)
+		upper = wallClock(isoY+1, 1, 4-(int(jan4.Weekday())+6)%7, 0, loc)
+	case UNIT_MONTH:
+		next = wallClock(Y, M+time.Month(n), 1, 0, loc)
+		upper = wallClock(Y+1, 1, 1, 0, loc)
+	case UNIT_YEAR:
+		next = wallClock(Y+n, M, 1, 0, loc)
+	}
+	if sm.Anchor.IsZero() && !upper.IsZero() && upper.Before(next) {
+		next = upper
+	}
+	// always move forward, whatever the time zone
+	if !next.After(t) {
+		next = t.Add(sm.Duration())
+	}
+	return next
+}
+
+// Sub applies the step and substitutes the step increment to the given time.
+//
+// returns t unchanged if the unit is not an allowed value.
+func (sm StepMask) Sub(t time.Time) time.Time {
+	if !isValidMask(sm) {
+		return t
+	}
+	t, _ = sm.Apply(t)
+	t, _ = sm.Apply(t.Add(-time.Nanosecond))
+	return t
+}
+
+// multiple returns the number of units per step, at least 1
+func (sm StepMask) multiple() int {
+	if sm.Multiple < 1 {
+		return 1
+	}
+	return sm.Multiple
+}
+
+// nearest returns the predefined TimeMask the nearest of the step
+func (sm StepMask) nearest() TimeMask {
+	n := sm.multiple()
+	switch sm.Unit {
+	case UNIT_MILLISECOND:
+		return MASK_MILLISECOND
+	case UNIT_SECOND:
+		return MASK_SECOND
+	case UNIT_MINUTE:
+		return MASK_MINUTE
+	case UNIT_HOUR:
+		if n >= 12 {
+			return MASK_HALFDAY
+		}
+		return MASK_HOUR
+	case UNIT_DAY:
+		return MASK_DAY
+	case UNIT_WEEK:
+		return MASK_WEEK
+	case UNIT_MONTH:
+		if n >= 3 {
+			return MASK_QUARTER
+		}
+		return MASK_MONTH
+	}
+	return MASK_YEAR
+}
+
+// isValidMask returns true if mask can be used for scanning
+func isValidMask(mask Mask) bool {
+	switch m := mask.(type) {
+	case nil:
+		return false
+	case TimeMask:
+		return m >= MASK_min && m <= MASK_max
+	case StepMask:
+		return m.Unit >= UNIT_MILLISECOND && m.Unit <= UNIT_YEAR
+	}
+	return true
+}
+
+// wallClock returns the time at the wall clock Y-M-d h:00 in loc, like time.Date does, with a defined behavior for daylight saving time changes:
+//   - a wall clock skipped by a change is moved forward by the length of the change, so 02:00 is 03:00 when clocks go forward one hour at 02:00,
+//     and midnight is 01:00 in zones where clocks go forward at midnight.
+//   - a wall clock repeated by a change gets its first occurrence, before the change.
+func wallClock(Y int, M time.Month, d int, h int, loc *time.Location) time.Time {
+	t := time.Date(Y, M, d, h, 0, 0, 0, loc)
+
+	// skipped wall clock: time.Date may return a time before the change
+	want := time.Date(Y, M, d, h, 0, 0, 0, time.UTC)
+	got := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	if got.Before(want) {
+		t = t.Add(want.Sub(got))
+	}
+
+	return firstOccurrence(t)
+}
+
+// firstOccurrence returns the first time with the same wall clock than t, before the change if the wall clock is repeated by a daylight saving time change.
+func firstOccurrence(t time.Time) time.Time {
+	if start, _ := t.ZoneBounds(); !start.IsZero() {
+		_, offset := t.Zone()
+		_, formeroffset := start.Add(-time.Nanosecond).Zone()
+		if back := time.Duration(formeroffset-offset) * time.Second; back > 0 && t.Sub(start) < back {
+			t = t.Add(-back)
+		}
+	}
+	return t
+}
+
+// secondOccurrence returns the last time with the same wall clock than t, after the change if the wall clock is repeated by a daylight saving time change.
+func secondOccurrence(t time.Time) time.Time {
+	if _, end := t.ZoneBounds(); !end.IsZero() {
+		_, offset := t.Zone()
+		_, nextoffset := end.Zone()
+		if back := time.Duration(offset-nextoffset) * time.Second; back > 0 && end.Sub(t) <= back {
+			t = t.Add(back)
+		}
+	}
+	return t
+}
+
+// floorDiv returns a/b rounded toward negative infinity, b > 0
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b < 0 {
+		q--
+	}
+	return q
+}
+
+// floorDiv64 returns a/b rounded toward negative infinity, b > 0
+func floorDiv64(a, b int64) int64 {
+	q := a / b
+	if a%b < 0 {
+		q--
+	}
+	return q
+}
+
+// civilDays returns the number of days since 1970-01-01 of a calendar date
+func civilDays(y int, m time.Month, d int) int {
+	return int(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
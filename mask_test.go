@@ -0,0 +1,398 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestStepMask(t *testing.T) {
+	dte := func(Y int, M time.Month, d, h, m int) time.Time { return time.Date(Y, M, d, h, m, 0, 0, time.UTC) }
+	t1 := dte(2024, 5, 31, 14, 58)
+	cases := []struct {
+		mask      Mask
+		t         time.Time
+		wantapply time.Time
+		wantadd   time.Time
+		wantsub   time.Time
+	}{
+		{StepMask{Unit: UNIT_MINUTE, Multiple: 5}, t1, dte(2024, 5, 31, 14, 55), dte(2024, 5, 31, 15, 0), dte(2024, 5, 31, 14, 50)},
+		{StepMask{Unit: UNIT_MINUTE, Multiple: 7}, t1, dte(2024, 5, 31, 14, 56), dte(2024, 5, 31, 15, 0), dte(2024, 5, 31, 14, 49)},
+		{StepMask{Unit: UNIT_MINUTE, Multiple: 7}, dte(2024, 5, 31, 15, 2), dte(2024, 5, 31, 15, 0), dte(2024, 5, 31, 15, 7), dte(2024, 5, 31, 14, 56)},
+		{StepMask{Unit: UNIT_HOUR, Multiple: 2}, t1, dte(2024, 5, 31, 14, 0), dte(2024, 5, 31, 16, 0), dte(2024, 5, 31, 12, 0)},
+		{StepMask{Unit: UNIT_DAY, Multiple: 10}, t1, dte(2024, 5, 31, 0, 0), dte(2024, 6, 1, 0, 0), dte(2024, 5, 21, 0, 0)},
+		{StepMask{Unit: UNIT_MONTH, Multiple: 6}, t1, dte(2024, 1, 1, 0, 0), dte(2024, 7, 1, 0, 0), dte(2023, 7, 1, 0, 0)},
+		{StepMask{Unit: UNIT_YEAR, Multiple: 10}, t1, dte(2020, 1, 1, 0, 0), dte(2030, 1, 1, 0, 0), dte(2010, 1, 1, 0, 0)},
+		{StepMask{Unit: UNIT_YEAR, Multiple: 10}, dte(-5, 3, 1, 0, 0), dte(-10, 1, 1, 0, 0), dte(0, 1, 1, 0, 0), dte(-20, 1, 1, 0, 0)},
+		{StepMask{Unit: UNIT_MINUTE, Multiple: 90, Anchor: dte(2024, 1, 1, 0, 10)}, t1, dte(2024, 5, 31, 13, 40), dte(2024, 5, 31, 15, 10), dte(2024, 5, 31, 12, 10)},
+		{StepMask{Unit: UNIT_DAY, Multiple: 10, Anchor: dte(2024, 5, 25, 12, 0)}, t1, dte(2024, 5, 25, 0, 0), dte(2024, 6, 4, 0, 0), dte(2024, 5, 15, 0, 0)},
+		{StepMask{Unit: UNIT_DAY, Multiple: 10, Anchor: dte(2024, 6, 5, 0, 0)}, t1, dte(2024, 5, 26, 0, 0), dte(2024, 6, 5, 0, 0), dte(2024, 5, 16, 0, 0)},
+		{StepMask{Unit: UNIT_MONTH, Multiple: 3, Anchor: dte(2020, 2, 10, 0, 0)}, t1, dte(2024, 5, 1, 0, 0), dte(2024, 8, 1, 0, 0), dte(2024, 2, 1, 0, 0)},
+		{StepMask{Unit: UNIT_YEAR, Anchor: dte(2000, 4, 1, 0, 0)}, dte(2024, 2, 1, 0, 0), dte(2023, 4, 1, 0, 0), dte(2024, 4, 1, 0, 0), dte(2022, 4, 1, 0, 0)},
+		{MASK_QUARTER, dte(2024, 3, 31, 0, 0), dte(2024, 1, 1, 0, 0), dte(2024, 4, 1, 0, 0), dte(2023, 10, 1, 0, 0)},
+		{MASK_QUARTER, dte(2024, 12, 31, 0, 0), dte(2024, 10, 1, 0, 0), dte(2025, 1, 1, 0, 0), dte(2024, 7, 1, 0, 0)},
+		{MASK_QUARTER, dte(2024, 5, 2, 0, 0), dte(2024, 4, 1, 0, 0), dte(2024, 7, 1, 0, 0), dte(2024, 1, 1, 0, 0)},
+	}
+	for _, c := range cases {
+		if got, match := c.mask.Apply(c.t); !got.Equal(c.wantapply) || match != c.t.Equal(c.wantapply) {
+			t.Errorf("%s Apply %v fails: want %v got %v", c.mask, c.t, c.wantapply, got)
+		}
+		if got := c.mask.Add(c.t); !got.Equal(c.wantadd) {
+			t.Errorf("%s Add %v fails: want %v got %v", c.mask, c.t, c.wantadd, got)
+		}
+		if got := c.mask.Sub(c.t); !got.Equal(c.wantsub) {
+			t.Errorf("%s Sub %v fails: want %v got %v", c.mask, c.t, c.wantsub, got)
+		}
+	}
+
+	// predefined masks are steps, ranked by duration
+	for i, mask := range masksByDuration {
+		if i > 0 && mask.Duration() <= masksByDuration[i-1].Duration() || mask.rank() != i+1 || mask.step().nearest().rank() > mask.rank() {
+			t.Errorf("%s step fails: got %s", mask, mask.step())
+		}
+	}
+	if len(masksByDuration) != int(MASK_max) {
+		t.Errorf("masksByDuration fails: want %d masks got %d", MASK_max, len(masksByDuration))
+	}
+	if s := (StepMask{Unit: UNIT_DAY}).String(); s != "day" {
+		t.Errorf("String fails: got %q", s)
+	}
+}
+
+func TestGetTimeFormatUpfront(t *testing.T) {
+	formert := time.Date(2023, 12, 31, 12, 0, 0, 0, time.UTC)
+	newt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, c := range []struct {
+		mask   TimeMask
+		former string // the output before v2.6.0
+		want   string
+	}{
+		{MASK_HOUR, "2024, Jan, Mon 01, 00:00", "2024, Jan, Mon 01, 00:00"},
+		{MASK_HALFDAY, "2024, Jan, Mon 01, Mon 01 00:00", "2024, Jan, Mon 01 00:00"},
+		{MASK_DAY, "2024, Jan, Mon 01", "2024, Jan, Mon 01"},
+		{MASK_MONTH, "2024, Jan, Jan", "2024, Jan"},
+		{MASK_QUARTER, "2024, 2024 Jan", "2024 Jan"},
+		{MASK_YEAR, "2024", "2024"},
+	} {
+		if got := newt.Format(c.mask.GetTimeFormat(newt, formert)); got != c.want {
+			t.Errorf("%s GetTimeFormat fails: want %q got %q", c.mask, c.want, got)
+		}
+	}
+
+	// within the same year
+	newt = newt.AddDate(0, 1, 0)
+	formert = newt.AddDate(0, 0, -1)
+	for _, c := range []struct {
+		mask   TimeMask
+		former string // the output before v2.6.0
+		want   string
+	}{
+		{MASK_HOUR, "Feb, Thu 01, 00:00", "Feb, Thu 01, 00:00"},
+		{MASK_HALFDAY, "Feb, Thu 01, Thu 01 00:00", "Feb, Thu 01 00:00"},
+		{MASK_DAY, "Feb, Thu 01", "Feb, Thu 01"},
+		{MASK_MONTH, "Feb", "Feb"},
+	} {
+		if got := newt.Format(c.mask.GetTimeFormat(newt, formert)); got != c.want {
+			t.Errorf("%s GetTimeFormat fails: want %q got %q", c.mask, c.want, got)
+		}
+	}
+}
+
+func TestSubMinuteMasks(t *testing.T) {
+	dte := func(s, ms int) time.Time { return time.Date(2024, 5, 31, 14, 58, s, ms*1e6, time.UTC) }
+	t1 := time.Date(2024, 5, 31, 14, 58, 37, 456789, time.UTC).Add(123 * time.Millisecond)
+	cases := []struct {
+		mask      Mask
+		wantapply time.Time
+		wantadd   time.Time
+		wantsub   time.Time
+	}{
+		{MASK_MILLISECOND, dte(37, 123), dte(37, 124), dte(37, 122)},
+		{MASK_MILLISECONDx100, dte(37, 100), dte(37, 200), dte(37, 0)},
+		{MASK_SECOND, dte(37, 0), dte(38, 0), dte(36, 0)},
+		{MASK_SECONDx5, dte(35, 0), dte(40, 0), dte(30, 0)},
+		{MASK_SECONDx10, dte(30, 0), dte(40, 0), dte(20, 0)},
+		{MASK_SECONDx15, dte(30, 0), dte(45, 0), dte(15, 0)},
+		{MASK_SECONDx30, dte(30, 0), dte(60, 0), dte(0, 0)},
+		{StepMask{Unit: UNIT_MILLISECOND, Multiple: 300}, dte(37, 0), dte(37, 300), dte(36, 900)},
+		{StepMask{Unit: UNIT_SECOND, Multiple: 7}, dte(35, 0), dte(42, 0), dte(28, 0)},
+		{StepMask{Unit: UNIT_SECOND, Multiple: 2, Anchor: dte(1, 0)}, dte(37, 0), dte(39, 0), dte(35, 0)},
+	}
+	for _, c := range cases {
+		if got, _ := c.mask.Apply(t1); !got.Equal(c.wantapply) {
+			t.Errorf("%s Apply fails: want %v got %v", c.mask, c.wantapply, got)
+		}
+		if got := c.mask.Add(t1); !got.Equal(c.wantadd) {
+			t.Errorf("%s Add fails: want %v got %v", c.mask, c.wantadd, got)
+		}
+		if got := c.mask.Sub(t1); !got.Equal(c.wantsub) {
+			t.Errorf("%s Sub fails: want %v got %v", c.mask, c.wantsub, got)
+		}
+	}
+
+	// 7 seconds steps restart every minute
+	if got := (StepMask{Unit: UNIT_SECOND, Multiple: 7}).Add(dte(57, 0)); !got.Equal(dte(60, 0)) {
+		t.Errorf("Add fails: got %v", got)
+	}
+
+	// a 20 seconds trading window
+	ts := MakeTimeSlice(t1, 20*time.Second)
+	if got := ts.GetScanMask(10); got != MASK_SECONDx5 {
+		t.Errorf("GetScanMask fails: got %s", got)
+	}
+	if got := ts.GetScanMask(1000); got != MASK_MILLISECONDx100 {
+		t.Errorf("GetScanMask fails: got %s", got)
+	}
+	// a single date keeps getting the shortest mask from the minute, while a millisecond gets the shortest sub-second mask
+	if got := MakeTimeSlice(t1, 0).GetScanMask(10); got != MASK_SHORTEST {
+		t.Errorf("GetScanMask single date fails: got %s", got)
+	}
+	if got := MakeTimeSlice(t1, time.Millisecond).GetScanMask(10); got != MASK_SHORTEST_SUBSECOND {
+		t.Errorf("GetScanMask millisecond fails: got %s", got)
+	}
+	if got := MakeTimeSlice(t1, -time.Millisecond).GetScanMask(1); got != MASK_SHORTEST_SUBSECOND {
+		t.Errorf("GetScanMask millisecond fails: got %s", got)
+	}
+	if MASK_SHORTEST_SUBSECOND.rank() != 1 {
+		t.Errorf("MASK_SHORTEST_SUBSECOND fails: got %s", MASK_SHORTEST_SUBSECOND)
+	}
+
+	// formats
+	for _, c := range []struct {
+		mask          Mask
+		newt, formert time.Time
+		want          string
+	}{
+		{MASK_MILLISECONDx100, dte(37, 100), dte(37, 0), "37.100"},
+		{MASK_MILLISECONDx100, dte(0, 100), dte(59, 900).Add(-time.Minute), "14:58:00.100"},
+		{MASK_SECONDx5, dte(40, 0), dte(35, 0), "14:58:40"},
+		{StepMask{Unit: UNIT_MILLISECOND, Multiple: 250}, dte(37, 250), dte(37, 0), "37.250"},
+	} {
+		if got := c.mask.Format(c.newt, c.formert); got != c.want {
+			t.Errorf("%s Format fails: want %s got %s", c.mask, c.want, got)
+		}
+	}
+}
+
+func TestWeekMask(t *testing.T) {
+	dte := func(Y int, M time.Month, d int) time.Time { return time.Date(Y, M, d, 0, 0, 0, 0, time.UTC) }
+	wed := time.Date(2024, 2, 14, 10, 0, 0, 0, time.UTC)
+	cases := []struct {
+		mask      Mask
+		t         time.Time
+		wantapply time.Time
+		wantadd   time.Time
+		wantsub   time.Time
+	}{
+		{MASK_WEEK, wed, dte(2024, 2, 12), dte(2024, 2, 19), dte(2024, 2, 5)},
+		{MASK_WEEK, dte(2024, 1, 1), dte(2024, 1, 1), dte(2024, 1, 8), dte(2023, 12, 25)},
+		{WeekMask(time.Sunday), wed, dte(2024, 2, 11), dte(2024, 2, 18), dte(2024, 2, 4)},
+		{WeekMask(time.Saturday), dte(2024, 2, 10), dte(2024, 2, 10), dte(2024, 2, 17), dte(2024, 2, 3)},
+		{WeekMask(time.Monday), wed, dte(2024, 2, 12), dte(2024, 2, 19), dte(2024, 2, 5)},
+		// steps of 2 weeks start on odd ISO weeks, W53 of 2020 restarts at W01 of 2021
+		{StepMask{Unit: UNIT_WEEK, Multiple: 2}, wed, dte(2024, 2, 12), dte(2024, 2, 26), dte(2024, 1, 29)},
+		{StepMask{Unit: UNIT_WEEK, Multiple: 2}, wed.AddDate(0, 0, 7), dte(2024, 2, 12), dte(2024, 2, 26), dte(2024, 1, 29)},
+		{StepMask{Unit: UNIT_WEEK, Multiple: 2}, dte(2020, 12, 30), dte(2020, 12, 28), dte(2021, 1, 4), dte(2020, 12, 14)},
+	}
+	for _, c := range cases {
+		if got, _ := c.mask.Apply(c.t); !got.Equal(c.wantapply) {
+			t.Errorf("%s Apply %v fails: want %v got %v", c.mask, c.t, c.wantapply, got)
+		}
+		if got := c.mask.Add(c.t); !got.Equal(c.wantadd) {
+			t.Errorf("%s Add %v fails: want %v got %v", c.mask, c.t, c.wantadd, got)
+		}
+		if got := c.mask.Sub(c.t); !got.Equal(c.wantsub) {
+			t.Errorf("%s Sub %v fails: want %v got %v", c.mask, c.t, c.wantsub, got)
+		}
+	}
+
+	for _, c := range []struct {
+		mask Mask
+		t    time.Time
+		want string
+	}{
+		{MASK_WEEK, dte(2024, 2, 12), "2024-W07"},
+		{MASK_WEEK, dte(2024, 12, 30), "2025-W01"},
+		{MASK_WEEK, dte(2020, 12, 28), "2020-W53"},
+		{WeekMask(time.Sunday), dte(2024, 2, 11), "2024-W07"},
+		{WeekMask(time.Saturday), dte(2024, 2, 10), "2024-W07"},
+		{MASK_WEEK, dte(2024, 2, 17), "2024-W07"}, // a saturday
+		{MASK_WEEK, dte(2024, 3, 8), "2024-W10"},  // a friday
+		{WeekMask(time.Sunday), dte(2024, 2, 17), "2024-W07"},
+		{WeekMask(time.Sunday), dte(2024, 2, 18), "2024-W08"},
+		{MASK_DAY, dte(2024, 2, 12), "Mon 12"},
+	} {
+		if got := c.mask.Format(c.t, c.t); got != c.want {
+			t.Errorf("%s Format %v fails: want %s got %s", c.mask, c.t, c.want, got)
+		}
+	}
+
+	ts := MakeTimeSlice(wed, 20*Day)
+	if got := ts.GetScanMask(10); got != MASK_WEEK {
+		t.Errorf("GetScanMask fails: want week got %s", got)
+	}
+}
+
+func TestMaskDST(t *testing.T) {
+	load := func(name string) *time.Location {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return loc
+	}
+	paris, newyork, saopaulo := load("Europe/Paris"), load("America/New_York"), load("America/Sao_Paulo")
+	scan := func(ts TimeSlice, mask Mask, layout string) (str string) {
+		for tm := range ts.Times(mask, false) {
+			str += tm.Format(layout) + " "
+		}
+		return str
+	}
+	cases := []struct {
+		ts     TimeSlice
+		mask   Mask
+		layout string
+		want   string
+	}{
+		// spring forward in Paris, 02:00 -> 03:00
+		{TimeSlice{From: time.Date(2024, 3, 30, 0, 0, 0, 0, paris), To: time.Date(2024, 4, 2, 0, 0, 0, 0, paris)}, MASK_DAY, "02 15:04 MST", "30 00:00 CET 31 00:00 CET 01 00:00 CEST 02 00:00 CEST "},
+		{TimeSlice{From: time.Date(2024, 3, 31, 0, 0, 0, 0, paris), To: time.Date(2024, 3, 31, 12, 0, 0, 0, paris)}, MASK_HOURx4, "15:04 MST", "00:00 CET 04:00 CEST 08:00 CEST 12:00 CEST "},
+		{TimeSlice{From: time.Date(2024, 3, 31, 0, 0, 0, 0, paris), To: time.Date(2024, 3, 31, 4, 0, 0, 0, paris)}, MASK_HOUR, "15:04 MST", "00:00 CET 01:00 CET 03:00 CEST 04:00 CEST "},
+		{TimeSlice{From: time.Date(2024, 3, 31, 0, 0, 0, 0, paris), To: time.Date(2024, 3, 31, 6, 0, 0, 0, paris)}, StepMask{Unit: UNIT_HOUR, Multiple: 2}, "15:04 MST", "00:00 CET 03:00 CEST 04:00 CEST 06:00 CEST "},
+		// fall back in Paris, 03:00 -> 02:00, the repeated hour is scanned twice by hours, once by multiple hours
+		{TimeSlice{From: time.Date(2024, 10, 26, 0, 0, 0, 0, paris), To: time.Date(2024, 10, 29, 0, 0, 0, 0, paris)}, MASK_DAY, "02 15:04 MST", "26 00:00 CEST 27 00:00 CEST 28 00:00 CET 29 00:00 CET "},
+		{TimeSlice{From: time.Date(2024, 10, 27, 0, 0, 0, 0, paris), To: time.Date(2024, 10, 27, 4, 0, 0, 0, paris)}, MASK_HOUR, "15:04 MST", "00:00 CEST 01:00 CEST 02:00 CEST 02:00 CET 03:00 CET 04:00 CET "},
+		{TimeSlice{From: time.Date(2024, 10, 27, 0, 0, 0, 0, paris), To: time.Date(2024, 10, 27, 6, 0, 0, 0, paris)}, StepMask{Unit: UNIT_HOUR, Multiple: 2}, "15:04 MST", "00:00 CEST 02:00 CEST 04:00 CET 06:00 CET "},
+		{TimeSlice{From: time.Date(2024, 10, 27, 1, 50, 0, 0, paris), To: time.Date(2024, 10, 27, 3, 0, 0, 0, paris)}, MASK_HALFHOUR, "15:04 MST", "02:00 CEST 02:30 CEST 02:00 CET 02:30 CET 03:00 CET "},
+		// half-days in New York
+		{TimeSlice{From: time.Date(2024, 3, 10, 0, 0, 0, 0, newyork), To: time.Date(2024, 3, 11, 0, 0, 0, 0, newyork)}, MASK_HALFDAY, "02 15:04 MST", "10 00:00 EST 10 12:00 EDT 11 00:00 EDT "},
+		{TimeSlice{From: time.Date(2024, 11, 3, 0, 0, 0, 0, newyork), To: time.Date(2024, 11, 4, 0, 0, 0, 0, newyork)}, MASK_HALFDAY, "02 15:04 MST", "03 00:00 EDT 03 12:00 EST 04 00:00 EST "},
+		// clocks went forward at midnight in Sao Paulo, the day starts at 01:00
+		{TimeSlice{From: time.Date(2018, 11, 3, 0, 0, 0, 0, saopaulo), To: time.Date(2018, 11, 5, 0, 0, 0, 0, saopaulo)}, MASK_DAY, "02 15:04 -07", "03 00:00 -03 04 01:00 -02 05 00:00 -02 "},
+		// anti-chronological
+		{TimeSlice{From: time.Date(2024, 4, 1, 0, 0, 0, 0, paris), To: time.Date(2024, 3, 30, 0, 0, 0, 0, paris)}, MASK_DAY, "02 15:04 MST", "01 00:00 CEST 31 00:00 CET 30 00:00 CET "},
+		{TimeSlice{From: time.Date(2024, 10, 27, 4, 0, 0, 0, paris), To: time.Date(2024, 10, 27, 0, 0, 0, 0, paris)}, MASK_HOUR, "15:04 MST", "04:00 CET 03:00 CET 02:00 CET 02:00 CEST 01:00 CEST 00:00 CEST "},
+	}
+	for _, c := range cases {
+		if got := scan(c.ts, c.mask, c.layout); got != c.want {
+			t.Errorf("Scan %v with %s fails:\nwant %q\ngot  %q", c.ts, c.mask, c.want, got)
+		}
+	}
+
+	// always move forward on the mask, over several years and zones
+	masks := []Mask{MASK_MINUTEx15, MASK_HOUR, MASK_HOURx4, MASK_HALFDAY, MASK_DAY, MASK_WEEK, MASK_MONTH, StepMask{Unit: UNIT_HOUR, Multiple: 3}, StepMask{Unit: UNIT_DAY, Multiple: 2, Anchor: time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)}}
+	for _, name := range []string{"Europe/Paris", "America/New_York", "America/Sao_Paulo", "Australia/Lord_Howe", "Asia/Beirut", "America/Havana", "Pacific/Apia"} {
+		loc := load(name)
+		for _, mask := range masks {
+			ts := TimeSlice{From: time.Date(2016, 1, 1, 0, 0, 0, 0, loc), To: time.Date(2020, 1, 1, 0, 0, 0, 0, loc)}
+			if mask.Duration() < time.Hour {
+				ts.To = ts.From.AddDate(1, 0, 0)
+			}
+			var former time.Time
+			for tm := range ts.Times(mask, false) {
+				if !tm.After(former) {
+					t.Errorf("%s %s does not move forward: %v after %v", name, mask, tm, former)
+					break
+				}
+				if masked, exact := mask.Apply(tm); !exact {
+					t.Errorf("%s %s does not match the mask: %v applied to %v", name, mask, tm, masked)
+					break
+				}
+				if sub := mask.Sub(tm); !former.IsZero() && !sub.Equal(former) {
+					t.Errorf("%s %s Sub fails: %v gives %v, want %v", name, mask, tm, sub, former)
+					break
+				}
+				former = tm
+			}
+		}
+	}
+}
+
+func TestScanStepMask(t *testing.T) {
+	ts := TimeSlice{From: time.Date(2024, 1, 1, 9, 2, 0, 0, time.UTC), To: time.Date(2024, 1, 1, 9, 20, 0, 0, time.UTC)}
+	five := StepMask{Unit: UNIT_MINUTE, Multiple: 5}
+
+	var got string
+	for tm := range ts.Times(five, false) {
+		got += tm.Format("04 ")
+	}
+	if got != "05 10 15 20 " {
+		t.Errorf("Scan chrono fails: got %q", got)
+	}
+
+	got = ""
+	ts.ForceDirection(AntiChronological)
+	for tm := range ts.Times(five, true) {
+		got += tm.Format("04 ")
+	}
+	if got != "20 15 10 05 02 " {
+		t.Errorf("Scan antichrono fails: got %q", got)
+	}
+}
+
+func TestGetScanMaskAmong(t *testing.T) {
+	candidates := []Mask{MASK_HOUR, StepMask{Unit: UNIT_MINUTE, Multiple: 5}, StepMask{Unit: UNIT_HOUR, Multiple: 2}, MASK_DAY}
+	ts := MakeTimeSlice(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 0)
+	cases := []struct {
+		d    time.Duration
+		max  uint
+		want string
+	}{
+		{0, 10, "5 minutes"},
+		{50 * time.Minute, 10, "5 minutes"},
+		{-50 * time.Minute, 10, "5 minutes"},
+		{51 * time.Minute, 10, "hour"},
+		{11 * time.Hour, 10, "2 hours"},
+		{30 * Day, 10, "day"},
+	}
+	for _, c := range cases {
+		ts.To = ts.From.Add(c.d)
+		if got := ts.GetScanMaskAmong(c.max, candidates...); got.String() != c.want {
+			t.Errorf("GetScanMaskAmong %v fails: want %s got %s", c.d, c.want, got)
+		}
+	}
+	if got := ts.GetScanMaskAmong(10); got != MASK_NONE {
+		t.Errorf("GetScanMaskAmong without candidates fails: got %v", got)
+	}
+	if got := ts.GetScanMaskAmong(10, nil, MASK_HOUR, nil); got != MASK_HOUR {
+		t.Errorf("GetScanMaskAmong with nil candidates fails: got %v", got)
+	}
+	if got := ts.GetScanMaskAmong(10, nil); got != MASK_NONE {
+		t.Errorf("GetScanMaskAmong with a nil candidate fails: got %v", got)
+	}
+	if got := (TimeSlice{From: ts.From}).GetScanMaskAmong(10, candidates...); got != MASK_NONE {
+		t.Errorf("GetScanMaskAmong infinite fails: got %v", got)
+	}
+}
+
+func ExampleStepMask() {
+	ts := TimeSlice{From: time.Date(2024, 1, 1, 8, 50, 0, 0, time.UTC), To: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+
+	// every 45 minutes from 9:15
+	mask := StepMask{Unit: UNIT_MINUTE, Multiple: 45, Anchor: time.Date(2024, 1, 1, 9, 15, 0, 0, time.UTC)}
+	for t := range ts.Times(mask, false) {
+		fmt.Print(t.Format(mask.GetTimeFormat(t, t)), " ")
+	}
+	fmt.Println()
+
+	// Output: 09:15 10:00 10:45 11:30
+}
+
+func ExampleWeekMask() {
+	ts := TimeSlice{From: time.Date(2024, 12, 20, 0, 0, 0, 0, time.UTC), To: time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC)}
+
+	mask := WeekMask(time.Sunday)
+	for t := range ts.Times(mask, false) {
+		fmt.Println(t.Format("Mon 2006-01-02"), mask.Format(t, t))
+	}
+
+	// Output:
+	// Sun 2024-12-22 2024-W52
+	// Sun 2024-12-29 2025-W01
+	// Sun 2025-01-05 2025-W02
+	// Sun 2025-01-12 2025-W03
+	// Sun 2025-01-19 2025-W04
+}
@@ -0,0 +1,189 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"fmt"
+	"time"
+)
+
+// Tick is a trade at a time, with its price and its volume.
+type Tick struct {
+	Time   time.Time
+	Price  float64
+	Volume float64
+}
+
+// Candle aggregates the ticks within a bucket into open, high, low, close and volume values.
+type Candle struct {
+	TimeSlice // the bucket of the candle, the begining is included but not the end
+	Open      float64
+	High      float64
+	Low       float64
+	Close     float64
+	Volume    float64
+	Count     int // the number of ticks, 0 for an empty bucket carrying the former close forward
+}
+
+// EmptyPolicy defines the candles of buckets without any ticks.
+type EmptyPolicy int
+
+const (
+	EMPTY_SKIP  EmptyPolicy = 1 // empty buckets do not have any candle
+	EMPTY_CARRY EmptyPolicy = 2 // empty buckets get a candle with the former close, and a zero volume
+)
+
+// Resampler aggregates ticks into candles, with one candle per mask bucket.
+//
+// Buckets are the steps of the mask, truncated by Within and by the session containing the ticks if Sessions is defined.
+// With Sessions, ticks out of any session are ignored and empty buckets are only within sessions.
+//
+// Ticks are added incrementally with Add, and must be in chronological order.
+type Resampler struct {
+	Mask     Mask             // the mask defining the buckets
+	Within   TimeSlice        // ticks out of Within are ignored, a zero timeslice accepts all ticks
+	Sessions *SessionCalendar // buckets are within sessions if not nil
+	Empty    EmptyPolicy      // EMPTY_SKIP if zero
+
+	candles []Candle
+	last    time.Time // time of the last tick added
+}
+
+// NewResampler returns a resampler of candles for every step of mask.
+func NewResampler(mask Mask, empty EmptyPolicy) *Resampler {
+	return &Resampler{Mask: mask, Empty: empty}
+}
+
+// Add aggregates ticks into the candles, updating the last candle or appending new ones.
+// With EMPTY_CARRY, candles of empty buckets between two ticks are appended.
+//
+// Returns the number of ticks aggregated. Ticks out of Within or out of sessions are ignored.
+// Returns an error wrapping ErrInvalidMask if the mask can not be used, or an error if a tick is older than the former one
+// or before the last candle, then the ticks before it are already aggregated.
+func (r *Resampler) Add(ticks ...Tick) (n int, err error) {
+	if err := CheckMask(r.Mask); err != nil {
+		return 0, err
+	}
+	within := r.Within
+	within.ForceDirection(Chronological)
+	for _, tick := range ticks {
+		if tick.Time.Before(r.last) {
+			return n, fmt.Errorf("unable to add a tick at %v older than the former one at %v", tick.Time, r.last)
+		}
+		if tick.Time.IsZero() || startBefore(tick.Time, within.From) || !endAfter(within.To, tick.Time) {
+			continue
+		}
+		bucket, ok := r.bucket(tick.Time)
+		if !ok {
+			continue
+		}
+		last := len(r.candles) - 1
+		if last >= 0 && bucket.From.Before(r.candles[last].From) {
+			return n, fmt.Errorf("unable to add a tick at %v before the last candle", tick.Time)
+		}
+		r.last = tick.Time
+		n++
+
+		if last >= 0 && bucket.From.Equal(r.candles[last].From) {
+			c := &r.candles[last]
+			if c.Count == 0 {
+				c.Open, c.High, c.Low = tick.Price, tick.Price, tick.Price
+			}
+			c.High = max(c.High, tick.Price)
+			c.Low = min(c.Low, tick.Price)
+			c.Close = tick.Price
+			c.Volume += tick.Volume
+			c.Count++
+			continue
+		}
+		r.FillUntil(bucket.From)
+		r.candles = append(r.candles, Candle{TimeSlice: bucket, Open: tick.Price, High: tick.Price, Low: tick.Price, Close: tick.Price, Volume: tick.Volume, Count: 1})
+	}
+	return n, nil
+}
+
+// FillUntil appends the candles of the empty buckets starting before t, after the last candle, with EMPTY_CARRY only.
+// Useful to extend the candles up to now when ticks do not arrive anymore.
+// Nothing occurs without a former candle as there's no close to carry forward.
+func (r *Resampler) FillUntil(t time.Time) {
+	if r.Empty != EMPTY_CARRY || len(r.candles) == 0 || CheckMask(r.Mask) != nil {
+		return
+	}
+	within := r.Within
+	within.ForceDirection(Chronological)
+	if !within.To.IsZero() && t.After(within.To) {
+		t = within.To
+	}
+	for cursor := r.candles[len(r.candles)-1].To; cursor.Before(t); {
+		bucket, ok := r.bucket(cursor)
+		if !ok {
+			// move to the next session
+			next, ok := r.Sessions.NextOpen(cursor)
+			if !ok {
+				return
+			}
+			cursor = next.From
+			continue
+		}
+		if !bucket.From.Before(t) {
+			return
+		}
+		former := r.candles[len(r.candles)-1].Close
+		r.candles = append(r.candles, Candle{TimeSlice: bucket, Open: former, High: former, Low: former, Close: former})
+		cursor = bucket.To
+	}
+}
+
+// Candles returns a copy of the candles, in chronological order.
+func (r *Resampler) Candles() []Candle {
+	return append([]Candle{}, r.candles...)
+}
+
+// Last returns the last candle, which is still updated by new ticks within its bucket.
+//
+// returns false if there's no candle yet.
+func (r *Resampler) Last() (Candle, bool) {
+	if len(r.candles) == 0 {
+		return Candle{}, false
+	}
+	return r.candles[len(r.candles)-1], true
+}
+
+// bucket returns the bucket containing t, truncated by Within and by the session containing t.
+//
+// returns false if t is out of sessions.
+func (r *Resampler) bucket(t time.Time) (bucket TimeSlice, ok bool) {
+	bucket.From, _ = r.Mask.Apply(t.Round(0))
+	bucket.To = r.Mask.Add(bucket.From)
+	within := r.Within
+	within.ForceDirection(Chronological)
+	if r.Sessions != nil {
+		session, ok := r.Sessions.SessionAt(t)
+		if !ok {
+			return TimeSlice{}, false
+		}
+		within, _ = within.Intersect(session.TimeSlice)
+	}
+	if !within.From.IsZero() && bucket.From.Before(within.From) {
+		bucket.From = within.From
+	}
+	if !within.To.IsZero() && bucket.To.After(within.To) {
+		bucket.To = within.To
+	}
+	return bucket, true
+}
+
+// Resample aggregates ticks into candles of every step of mask within a timeslice, see Resampler.
+// With EMPTY_CARRY, empty buckets are carried forward up to the end of within.
+func Resample(ticks []Tick, mask Mask, within TimeSlice, empty EmptyPolicy) ([]Candle, error) {
+	r := &Resampler{Mask: mask, Within: within, Empty: empty}
+	if _, err := r.Add(ticks...); err != nil {
+		return nil, err
+	}
+	within.ForceDirection(Chronological)
+	if !within.To.IsZero() {
+		r.FillUntil(within.To)
+	}
+	return r.Candles(), nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestResample(t *testing.T) {
+	dte := func(h, m int) time.Time { return time.Date(2024, 1, 2, h, m, 0, 0, time.UTC) }
+	ticks := []Tick{
+		{dte(9, 0), 10, 1},
+		{dte(9, 5), 12, 2},
+		{dte(9, 10), 9, 1},
+		{dte(9, 14), 11, 3},
+		{dte(9, 47), 20, 1},
+	}
+
+	candles, err := Resample(ticks, MASK_MINUTEx15, TimeSlice{}, EMPTY_SKIP)
+	if err != nil || len(candles) != 2 {
+		t.Fatalf("Resample skip fails: got %v %v", candles, err)
+	}
+	want := Candle{TimeSlice: TimeSlice{From: dte(9, 0), To: dte(9, 15)}, Open: 10, High: 12, Low: 9, Close: 11, Volume: 7, Count: 4}
+	if candles[0] != want {
+		t.Errorf("Resample candle fails: want %+v got %+v", want, candles[0])
+	}
+	if candles[1].From != dte(9, 45) || candles[1].Close != 20 {
+		t.Errorf("Resample candle fails: got %+v", candles[1])
+	}
+
+	// empty buckets carry the former close forward, up to the end of within
+	candles, err = Resample(ticks, MASK_MINUTEx15, TimeSlice{From: dte(9, 0), To: dte(10, 30)}, EMPTY_CARRY)
+	if err != nil || len(candles) != 6 {
+		t.Fatalf("Resample carry fails: got %d %v", len(candles), err)
+	}
+	for i, c := range []Candle{candles[1], candles[2], candles[4], candles[5]} {
+		if c.Count != 0 || c.Volume != 0 || c.Open != c.Close || c.High != c.Low {
+			t.Errorf("Resample carry %d fails: got %+v", i, c)
+		}
+	}
+	if candles[1].Close != 11 || candles[5].Close != 20 || candles[5].To != dte(10, 30) {
+		t.Errorf("Resample carry fails: got %+v %+v", candles[1], candles[5])
+	}
+
+	// within truncates buckets and ignores ticks
+	candles, _ = Resample(ticks, MASK_HOUR, TimeSlice{From: dte(9, 10), To: dte(9, 30)}, EMPTY_SKIP)
+	if len(candles) != 1 || candles[0].TimeSlice != (TimeSlice{From: dte(9, 10), To: dte(9, 30)}) || candles[0].Count != 2 {
+		t.Errorf("Resample within fails: got %+v", candles)
+	}
+
+	// custom masks
+	candles, _ = Resample(ticks, StepMask{Unit: UNIT_MINUTE, Multiple: 7}, TimeSlice{}, EMPTY_SKIP)
+	if len(candles) != 4 || candles[1].From != dte(9, 7) || candles[1].Count != 1 {
+		t.Errorf("Resample custom mask fails: got %+v", candles)
+	}
+
+	// errors
+	if _, err := Resample(ticks, MASK_NONE, TimeSlice{}, EMPTY_SKIP); !errors.Is(err, ErrInvalidMask) {
+		t.Errorf("Resample invalid mask fails: got %v", err)
+	}
+	if _, err := Resample([]Tick{ticks[1], ticks[0]}, MASK_HOUR, TimeSlice{}, EMPTY_SKIP); err == nil {
+		t.Errorf("Resample unordered ticks fails")
+	}
+}
+
+func TestResamplerIncremental(t *testing.T) {
+	dte := func(h, m int) time.Time { return time.Date(2024, 1, 2, h, m, 0, 0, time.UTC) }
+	r := NewResampler(MASK_MINUTE, EMPTY_CARRY)
+	if _, ok := r.Last(); ok {
+		t.Errorf("Last fails")
+	}
+
+	r.Add(Tick{dte(9, 0), 10, 1})
+	r.Add(Tick{dte(9, 0).Add(30 * time.Second), 11, 1})
+	if last, _ := r.Last(); last.Close != 11 || last.Count != 2 {
+		t.Errorf("Add fails: got %+v", last)
+	}
+
+	// no ticks for a while
+	r.FillUntil(dte(9, 3).Add(10 * time.Second))
+	if candles := r.Candles(); len(candles) != 4 || candles[3].From != dte(9, 3) || candles[3].Count != 0 {
+		t.Errorf("FillUntil fails: got %+v", candles)
+	}
+
+	// a tick updates the carried candle of its bucket
+	if n, err := r.Add(Tick{dte(9, 3).Add(20 * time.Second), 12, 5}); n != 1 || err != nil {
+		t.Errorf("Add fails: got %d %v", n, err)
+	}
+	if last, _ := r.Last(); last.Open != 12 || last.Low != 12 || last.Volume != 5 || last.Count != 1 {
+		t.Errorf("Add after FillUntil fails: got %+v", last)
+	}
+	if _, err := r.Add(Tick{dte(9, 1), 12, 5}); err == nil {
+		t.Errorf("Add older tick fails")
+	}
+}
+
+func TestResamplerSessions(t *testing.T) {
+	sc, _ := ParseSessionCalendar("session regular 09:30-16:00")
+	dte := func(d, h, m int) time.Time { return time.Date(2024, 1, d, h, m, 0, 0, time.UTC) }
+
+	r := NewResampler(MASK_HOUR, EMPTY_CARRY)
+	r.Sessions = &sc
+	n, err := r.Add(
+		Tick{dte(2, 9, 0), 9, 1},   // out of session
+		Tick{dte(2, 9, 40), 10, 1}, // first bucket truncated by the session
+		Tick{dte(2, 15, 10), 11, 1},
+		Tick{dte(3, 10, 5), 12, 1}, // next session
+	)
+	if err != nil || n != 3 {
+		t.Fatalf("Add fails: got %d %v", n, err)
+	}
+	candles := r.Candles()
+	if len(candles) != 9 {
+		t.Fatalf("Add fails: got %d candles", len(candles))
+	}
+	if candles[0].TimeSlice != (TimeSlice{From: dte(2, 9, 30), To: dte(2, 10, 0)}) {
+		t.Errorf("session bucket fails: got %v", candles[0].TimeSlice)
+	}
+	// empty buckets only within sessions
+	if candles[7].TimeSlice != (TimeSlice{From: dte(3, 9, 30), To: dte(3, 10, 0)}) || candles[7].Close != 11 || candles[7].Count != 0 {
+		t.Errorf("session carry fails: got %+v", candles[7])
+	}
+}
+
+func ExampleResample() {
+	t0 := time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)
+	ticks := []Tick{
+		{Time: t0, Price: 100, Volume: 10},
+		{Time: t0.Add(2 * time.Minute), Price: 104, Volume: 5},
+		{Time: t0.Add(4 * time.Minute), Price: 101, Volume: 8},
+		{Time: t0.Add(11 * time.Minute), Price: 99, Volume: 3},
+	}
+	candles, _ := Resample(ticks, StepMask{Unit: UNIT_MINUTE, Multiple: 5}, MakeTimeSlice(t0, 15*time.Minute), EMPTY_CARRY)
+	for _, c := range candles {
+		fmt.Printf("%s O:%v H:%v L:%v C:%v V:%v\n", c.From.Format("15:04"), c.Open, c.High, c.Low, c.Close, c.Volume)
+	}
+
+	// Output:
+	// 09:00 O:100 H:104 L:100 C:101 V:23
+	// 09:05 O:101 H:101 L:101 C:101 V:0
+	// 09:10 O:99 H:99 L:99 C:99 V:3
+}
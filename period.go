@@ -0,0 +1,144 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"fmt"
+	"time"
+)
+
+// MonthEnd defines how a Period handles a day which does not exist in the target month,
+// like when adding one month to January 31st:
+//   - MONTHEND_OVERFLOW: normalized like time.AddDate does, so January 31st plus one month is March 2nd or 3rd.
+//   - MONTHEND_CLAMP: clamped to the last day of the target month, so January 31st plus one month is February 28th or 29th.
+//   - MONTHEND_STICKY: like MONTHEND_CLAMP, and the last day of a month stays the last day of the target month, so February 29th plus one month is March 31st.
+type MonthEnd int
+
+const (
+	MONTHEND_OVERFLOW MonthEnd = 0
+	MONTHEND_CLAMP    MonthEnd = 1
+	MONTHEND_STICKY   MonthEnd = 2
+)
+
+// Period is a calendar-aware duration, with separate years, months, days and clock components.
+//
+// Unlike Duration which uses average months and years, a Period follows the calendar: years, months and days are added
+// like time.AddDate does, keeping the wall clock across daylight saving time changes, then hours, minutes and seconds are added as an exact duration.
+//
+// Components can be negative to move backward.
+type Period struct {
+	Years    int
+	Months   int
+	Days     int
+	Hours    int
+	Minutes  int
+	Seconds  int
+	MonthEnd MonthEnd // how to handle days which do not exist in the target month
+}
+
+// IsZero returns true if all components of the period are zero
+func (p Period) IsZero() bool {
+	return p.Years == 0 && p.Months == 0 && p.Days == 0 && p.Hours == 0 && p.Minutes == 0 && p.Seconds == 0
+}
+
+// Negate returns the period with all its components negated, to move backward.
+func (p Period) Negate() Period {
+	p.Years, p.Months, p.Days = -p.Years, -p.Months, -p.Days
+	p.Hours, p.Minutes, p.Seconds = -p.Hours, -p.Minutes, -p.Seconds
+	return p
+}
+
+// Clock returns the exact duration of the hours, minutes and seconds components.
+func (p Period) Clock() time.Duration {
+	return time.Duration(p.Hours)*time.Hour + time.Duration(p.Minutes)*time.Minute + time.Duration(p.Seconds)*time.Second
+}
+
+// AddTo returns t moved by the period, in the location of t.
+//
+// returns a zero time if t is a zero time.
+func (p Period) AddTo(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	y, m, d := t.Date()
+
+	// target month
+	months := int(m) - 1 + p.Months + 12*p.Years
+	ty := y + months/12
+	if months%12 < 0 {
+		ty--
+	}
+	tm := time.Month((months%12+12)%12 + 1)
+
+	// month end policy
+	if p.MonthEnd != MONTHEND_OVERFLOW {
+		last := daysIn(ty, tm)
+		if d > last || (p.MonthEnd == MONTHEND_STICKY && d == daysIn(y, m)) {
+			d = last
+		}
+	}
+
+	t = time.Date(ty, tm, d+p.Days, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	return t.Add(p.Clock())
+}
+
+// String returns the period formated like "1Y2M3d4h5m6s", only non zero components are output.
+// A zero period returns "0".
+func (p Period) String() (str string) {
+	components := []struct {
+		v    int
+		unit string
+	}{{p.Years, "Y"}, {p.Months, "M"}, {p.Days, "d"}, {p.Hours, "h"}, {p.Minutes, "m"}, {p.Seconds, "s"}}
+	for _, c := range components {
+		if c.v != 0 {
+			str += fmt.Sprintf("%d%s", c.v, c.unit)
+		}
+	}
+	if str == "" {
+		return "0"
+	}
+	return str
+}
+
+// MakeTimeSlicePeriod creates and returns a new timeslice starting at dte and lasting the period p.
+//   - If p is zero then the timeslice represents a single time.
+//   - If p is negative then the given time represents the end
+//
+// panic if the given date is not defined (zero time), use NewTimeSlicePeriod to get an error instead.
+func MakeTimeSlicePeriod(dte time.Time, p Period) TimeSlice {
+	if dte.IsZero() {
+		panic(dte)
+	}
+	return TimeSlice{From: dte, To: p.AddTo(dte)}
+}
+
+// NewTimeSlicePeriod creates and returns a new timeslice like MakeTimeSlicePeriod does.
+//
+// returns an error wrapping ErrInfinite if the given date is not defined (zero time)
+func NewTimeSlicePeriod(dte time.Time, p Period) (TimeSlice, error) {
+	if dte.IsZero() {
+		return TimeSlice{}, fmt.Errorf("unable to make a timeslice from an undefined date: %w", ErrInfinite)
+	}
+	return TimeSlice{From: dte, To: p.AddTo(dte)}, nil
+}
+
+// ExtendToPeriod add the period at the end of the timeslice.
+//   - if the period is negative then the end time moves backward.
+//   - if *pts.To is infinite, then nothing occurs.
+//
+// The timeslice direction can change.
+func (pts *TimeSlice) ExtendToPeriod(p Period) *TimeSlice {
+	pts.To = p.AddTo(pts.To)
+	return pts
+}
+
+// ExtendFromPeriod add the period at the begining of the timeslice.
+//   - if the period is negative then the begining time moves backward.
+//   - if *pts.From is infinite, then nothing occurs.
+//
+// The timeslice direction can change.
+func (pts *TimeSlice) ExtendFromPeriod(p Period) *TimeSlice {
+	pts.From = p.AddTo(pts.From)
+	return pts
+}
@@ -0,0 +1,90 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPeriodAddTo(t *testing.T) {
+	jan31 := time.Date(2023, 1, 31, 10, 0, 0, 0, time.UTC)
+	feb29 := time.Date(2024, 2, 29, 10, 0, 0, 0, time.UTC)
+	cases := []struct {
+		t    time.Time
+		p    Period
+		want time.Time
+	}{
+		{jan31, Period{Months: 1}, time.Date(2023, 3, 3, 10, 0, 0, 0, time.UTC)},
+		{jan31, Period{Months: 1, MonthEnd: MONTHEND_CLAMP}, time.Date(2023, 2, 28, 10, 0, 0, 0, time.UTC)},
+		{jan31, Period{Months: 1, MonthEnd: MONTHEND_STICKY}, time.Date(2023, 2, 28, 10, 0, 0, 0, time.UTC)},
+		{jan31, Period{Months: -2, MonthEnd: MONTHEND_CLAMP}, time.Date(2022, 11, 30, 10, 0, 0, 0, time.UTC)},
+		{jan31, Period{Years: 1, Months: 13, MonthEnd: MONTHEND_CLAMP}, time.Date(2025, 2, 28, 10, 0, 0, 0, time.UTC)},
+		{feb29, Period{Months: 1}, time.Date(2024, 3, 29, 10, 0, 0, 0, time.UTC)},
+		{feb29, Period{Months: 1, MonthEnd: MONTHEND_STICKY}, time.Date(2024, 3, 31, 10, 0, 0, 0, time.UTC)},
+		{feb29, Period{Years: 1, MonthEnd: MONTHEND_CLAMP}, time.Date(2025, 2, 28, 10, 0, 0, 0, time.UTC)},
+		{feb29, Period{Years: -1}, time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)},
+		{feb29, Period{Days: 1, Hours: 14, Minutes: 30}, time.Date(2024, 3, 2, 0, 30, 0, 0, time.UTC)},
+		{feb29, Period{}, feb29},
+	}
+	for _, c := range cases {
+		if got := c.p.AddTo(c.t); !got.Equal(c.want) {
+			t.Errorf("AddTo %v to %v fails: want %v got %v", c.p, c.t, c.want, got)
+		}
+	}
+	if got := (Period{Days: 1}).AddTo(time.Time{}); !got.IsZero() {
+		t.Errorf("AddTo zero time fails: got %v", got)
+	}
+
+	// days keep the wall clock across daylight saving time changes, hours do not
+	paris, _ := time.LoadLocation("Europe/Paris")
+	sat := time.Date(2024, 3, 30, 12, 0, 0, 0, paris)
+	if got := (Period{Days: 1}).AddTo(sat); got.Hour() != 12 || got.Sub(sat) != 23*time.Hour {
+		t.Errorf("AddTo across DST fails: got %v", got)
+	}
+	if got := (Period{Hours: 24}).AddTo(sat); got.Hour() != 13 {
+		t.Errorf("AddTo across DST fails: got %v", got)
+	}
+}
+
+func TestPeriodTimeSlice(t *testing.T) {
+	jan31 := time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC)
+	month := Period{Months: 1, MonthEnd: MONTHEND_CLAMP}
+
+	ts := MakeTimeSlicePeriod(jan31, month)
+	if !ts.To.Equal(time.Date(2023, 2, 28, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("MakeTimeSlicePeriod fails: got %v", ts)
+	}
+	ts.ExtendToPeriod(month)
+	if !ts.To.Equal(time.Date(2023, 3, 28, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("ExtendToPeriod fails: got %v", ts)
+	}
+	ts.ExtendFromPeriod(month.Negate())
+	if !ts.From.Equal(time.Date(2022, 12, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("ExtendFromPeriod fails: got %v", ts)
+	}
+
+	inf := TimeSlice{From: jan31}
+	if inf.ExtendToPeriod(month); !inf.To.IsZero() {
+		t.Errorf("ExtendToPeriod infinite fails: got %v", inf)
+	}
+}
+
+func ExamplePeriod_AddTo() {
+	jan31 := time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	for _, p := range []Period{
+		{Months: 1, MonthEnd: MONTHEND_OVERFLOW},
+		{Months: 1, MonthEnd: MONTHEND_CLAMP},
+		{Months: 2, Days: -1, Hours: 12},
+	} {
+		fmt.Printf("%s: %s\n", p, p.AddTo(jan31).Format("2006-01-02 15:04"))
+	}
+
+	// Output:
+	// 1M: 2023-03-03 00:00
+	// 1M: 2023-02-28 00:00
+	// 2M-1d12h: 2023-03-30 12:00
+}
@@ -0,0 +1,147 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PgRange wraps a TimeSlice to read and write PostgreSQL range types, tstzrange, tsrange and daterange,
+// with the sql.Scanner and driver.Valuer interfaces. TimeSlice can not implement them itself as its Scan method scans times with a mask.
+//
+// Unbounded ends are infinite boundaries. Empty is true for an empty range, then TimeSlice is zero.
+// Use sql.Null[PgRange] for a nullable column.
+type PgRange struct {
+	TimeSlice
+	Empty bool
+}
+
+// layouts of the PostgreSQL timestamp, timestamptz and date text output
+var pgLayouts = []string{
+	"2006-01-02 15:04:05.999999999Z07:00:00",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// pgLayout is the layout of the bounds output by Value
+const pgLayout = "2006-01-02 15:04:05.999999-07:00"
+
+// Value implements the driver.Valuer interface, the timeslice is output as a PostgreSQL range literal like:
+//
+//	["2024-01-01 00:00:00+00:00","2024-01-02 00:00:00+00:00")
+//
+// Infinite boundaries are unbounded and the timeslice is forced chronological. A single date is output as "empty".
+// PostgreSQL timestamps have a microsecond precision.
+func (r PgRange) Value() (driver.Value, error) {
+	ts := r.TimeSlice
+	ts.ForceDirection(Chronological)
+	if r.Empty || (!ts.From.IsZero() && ts.From.Equal(ts.To)) {
+		return "empty", nil
+	}
+	var strfrom, strto string
+	if !ts.From.IsZero() {
+		strfrom = `"` + ts.From.Format(pgLayout) + `"`
+	}
+	if !ts.To.IsZero() {
+		strto = `"` + ts.To.Format(pgLayout) + `"`
+	}
+	lower := "["
+	if ts.From.IsZero() {
+		lower = "("
+	}
+	return lower + strfrom + "," + strto + ")", nil
+}
+
+// Scan implements the sql.Scanner interface, to read a PostgreSQL tstzrange, tsrange or daterange in its text form like:
+//
+//	["2024-01-01 00:00:00+00","2024-01-02 00:00:00+00")
+//	[2024-01-01,)
+//
+// Unbounded and infinity bounds are read as infinite boundaries. Inclusive and exclusive bounds are accepted alike,
+// as a TimeSlice does not make the difference. Timestamps without time zone are in UTC.
+//
+// Returns an error if src is NULL.
+func (r *PgRange) Scan(src any) error {
+	var str string
+	switch v := src.(type) {
+	case string:
+		str = v
+	case []byte:
+		str = string(v)
+	case nil:
+		return errors.New("unable to scan NULL into a PgRange")
+	default:
+		return fmt.Errorf("unable to scan %T into a PgRange", src)
+	}
+
+	str = strings.TrimSpace(str)
+	if strings.EqualFold(str, "empty") {
+		*r = PgRange{Empty: true}
+		return nil
+	}
+	if len(str) < 3 || !strings.ContainsRune("[(", rune(str[0])) || !strings.ContainsRune("])", rune(str[len(str)-1])) {
+		return fmt.Errorf("invalid range: %q", str)
+	}
+	bounds, err := splitPgRange(str[1 : len(str)-1])
+	if err != nil {
+		return fmt.Errorf("invalid range: %q", str)
+	}
+
+	var ts TimeSlice
+	for i, pt := range []*time.Time{&ts.From, &ts.To} {
+		if *pt, err = parsePgTime(bounds[i]); err != nil {
+			return err
+		}
+	}
+	*r = PgRange{TimeSlice: ts}
+	return nil
+}
+
+// splitPgRange splits the inside of a range literal into its two bounds, removing quotes and escapes.
+func splitPgRange(str string) (bounds [2]string, err error) {
+	i := 0
+	quoted, escaped := false, false
+	for _, r := range str {
+		switch {
+		case escaped:
+			bounds[i] += string(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			if i == 1 {
+				return bounds, errors.New("too many bounds")
+			}
+			i++
+		default:
+			bounds[i] += string(r)
+		}
+	}
+	if i != 1 || quoted || escaped {
+		return bounds, errors.New("invalid bounds")
+	}
+	return bounds, nil
+}
+
+// parsePgTime parses a PostgreSQL range bound, returns a zero time for an unbounded or an infinity bound.
+func parsePgTime(str string) (time.Time, error) {
+	switch strings.ToLower(str) {
+	case "", "infinity", "-infinity":
+		return time.Time{}, nil
+	}
+	for _, layout := range pgLayouts {
+		if t, err := time.Parse(layout, str); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid range bound: %q", str)
+}
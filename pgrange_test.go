@@ -0,0 +1,83 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+)
+
+// check interfaces
+var _ sql.Scanner = (*PgRange)(nil)
+var _ driver.Valuer = PgRange{}
+
+func TestPgRangeScan(t *testing.T) {
+	jan1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	jan2 := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		src  any
+		want TimeSlice
+	}{
+		{`["2024-01-01 00:00:00+00","2024-01-02 00:00:00+00")`, TimeSlice{From: jan1, To: jan2}},
+		{[]byte(`[2024-01-01 00:00:00+00,2024-01-02 00:00:00+00)`), TimeSlice{From: jan1, To: jan2}},
+		{`["2024-01-01 05:30:00.5+05:30",)`, TimeSlice{From: jan1.Add(500 * time.Millisecond)}},
+		{`(,"2024-01-02 00:00:00")`, TimeSlice{To: jan2}},
+		{`[-infinity,infinity]`, TimeSlice{}},
+		{`(,)`, TimeSlice{}},
+		{`[2024-01-01,2024-01-02)`, TimeSlice{From: jan1, To: jan2}},
+	}
+	for _, c := range cases {
+		var r PgRange
+		if err := r.Scan(c.src); err != nil || r.Empty || !r.From.Equal(c.want.From) || !r.To.Equal(c.want.To) {
+			t.Errorf("Scan %s fails: want %v got %v %v", c.src, c.want, r, err)
+		}
+	}
+
+	r := PgRange{TimeSlice: TimeSlice{From: jan1}}
+	if err := r.Scan("empty"); err != nil || !r.Empty || !r.From.IsZero() {
+		t.Errorf("Scan empty fails: got %v %v", r, err)
+	}
+	for _, src := range []any{nil, 12, "", "[,", "2024-01-01,2024-01-02", "[2024-01-01)", "[a,b)", `["2024-01-01,2024-01-02)`, "[2024-01-01,2024-01-02,2024-01-03)"} {
+		if err := r.Scan(src); err == nil {
+			t.Errorf("Scan %v fails: want an error", src)
+		}
+	}
+}
+
+func TestPgRangeValue(t *testing.T) {
+	paris, _ := time.LoadLocation("Europe/Paris")
+	jan1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	jan2 := time.Date(2024, 1, 2, 0, 0, 0, 1000, paris)
+	cases := []struct {
+		ts   TimeSlice
+		want string
+	}{
+		{TimeSlice{From: jan1, To: jan2}, `["2024-01-01 00:00:00+00:00","2024-01-02 00:00:00.000001+01:00")`},
+		{TimeSlice{From: jan2, To: jan1}, `["2024-01-01 00:00:00+00:00","2024-01-02 00:00:00.000001+01:00")`},
+		{TimeSlice{From: jan1}, `["2024-01-01 00:00:00+00:00",)`},
+		{TimeSlice{To: jan1}, `(,"2024-01-01 00:00:00+00:00")`},
+		{TimeSlice{}, `(,)`},
+		{TimeSlice{From: jan1, To: jan1}, `empty`},
+	}
+	for _, c := range cases {
+		v, err := PgRange{TimeSlice: c.ts}.Value()
+		if err != nil || v != c.want {
+			t.Errorf("Value %v fails: want %s got %v %v", c.ts, c.want, v, err)
+		}
+		if c.want == "empty" {
+			continue
+		}
+		var back PgRange
+		chrono := c.ts
+		chrono.ForceDirection(Chronological)
+		if err := back.Scan(v); err != nil || !back.From.Equal(chrono.From) || !back.To.Equal(chrono.To) {
+			t.Errorf("Scan %v fails: got %v %v", v, back, err)
+		}
+	}
+	if v, _ := (PgRange{Empty: true}).Value(); v != "empty" {
+		t.Errorf("Value empty fails: got %v", v)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import "time"
+
+// Relation defines the position of a timeslice compared with another one, according to the 13 relations of the Allen's interval algebra:
+//   - REL_BEFORE, REL_AFTER
+//   - REL_MEETS, REL_MET_BY
+//   - REL_OVERLAPS, REL_OVERLAPPED_BY
+//   - REL_STARTS, REL_STARTED_BY
+//   - REL_DURING, REL_CONTAINS
+//   - REL_FINISHES, REL_FINISHED_BY
+//   - REL_EQUALS
+type Relation int
+
+const (
+	REL_BEFORE        Relation = 1
+	REL_MEETS         Relation = 2
+	REL_OVERLAPS      Relation = 3
+	REL_STARTS        Relation = 4
+	REL_DURING        Relation = 5
+	REL_FINISHES      Relation = 6
+	REL_EQUALS        Relation = 7
+	REL_FINISHED_BY   Relation = 8
+	REL_CONTAINS      Relation = 9
+	REL_STARTED_BY    Relation = 10
+	REL_OVERLAPPED_BY Relation = 11
+	REL_MET_BY        Relation = 12
+	REL_AFTER         Relation = 13
+)
+
+func (rel Relation) String() string {
+	switch rel {
+	case REL_BEFORE:
+		return "BEFORE"
+	case REL_MEETS:
+		return "MEETS"
+	case REL_OVERLAPS:
+		return "OVERLAPS"
+	case REL_STARTS:
+		return "STARTS"
+	case REL_DURING:
+		return "DURING"
+	case REL_FINISHES:
+		return "FINISHES"
+	case REL_EQUALS:
+		return "EQUALS"
+	case REL_FINISHED_BY:
+		return "FINISHED BY"
+	case REL_CONTAINS:
+		return "CONTAINS"
+	case REL_STARTED_BY:
+		return "STARTED BY"
+	case REL_OVERLAPPED_BY:
+		return "OVERLAPPED BY"
+	case REL_MET_BY:
+		return "MET BY"
+	case REL_AFTER:
+		return "AFTER"
+	}
+	return "?"
+}
+
+// Inverse returns the converse relation, so if tsa.Relation(tsb) is rel then tsb.Relation(tsa) is rel.Inverse().
+func (rel Relation) Inverse() Relation {
+	if rel < REL_BEFORE || rel > REL_AFTER {
+		return rel
+	}
+	return REL_AFTER + REL_BEFORE - rel
+}
+
+// Relation returns the Allen's relation of tsa compared with tsb.
+//
+// Both timeslices are considered in the chronological direction, so the relation does not depend on their direction.
+// An infinite begining is before any finite time, an infinite end is after any finite time, and two infinite begining or two infinite ends are equal.
+// So a zero timeslice EQUALS another zero timeslice, and CONTAINS any other timeslice.
+//
+// A single date is handled as a timeslice without duration. When several relations could apply to single dates,
+// EQUALS comes first, then STARTS and STARTED BY, then FINISHES and FINISHED BY, and finally MEETS and MET BY.
+func (tsa TimeSlice) Relation(tsb TimeSlice) Relation {
+	tsa.ForceDirection(Chronological)
+	tsb.ForceDirection(Chronological)
+
+	cmpfrom := cmpStart(tsa.From, tsb.From)
+	cmpto := cmpEnd(tsa.To, tsb.To)
+	switch {
+	case cmpfrom == 0 && cmpto == 0:
+		return REL_EQUALS
+	case endBeforeStart(tsa.To, tsb.From):
+		return REL_BEFORE
+	case endBeforeStart(tsb.To, tsa.From):
+		return REL_AFTER
+	case cmpfrom == 0 && cmpto < 0:
+		return REL_STARTS
+	case cmpfrom == 0:
+		return REL_STARTED_BY
+	case cmpto == 0 && cmpfrom > 0:
+		return REL_FINISHES
+	case cmpto == 0:
+		return REL_FINISHED_BY
+	case !tsa.To.IsZero() && tsa.To.Equal(tsb.From):
+		return REL_MEETS
+	case !tsb.To.IsZero() && tsb.To.Equal(tsa.From):
+		return REL_MET_BY
+	case cmpfrom < 0 && cmpto < 0:
+		return REL_OVERLAPS
+	case cmpfrom < 0:
+		return REL_CONTAINS
+	case cmpto > 0:
+		return REL_OVERLAPPED_BY
+	default:
+		return REL_DURING
+	}
+}
+
+// cmpStart compares two beginings, a zero time being an infinite past.
+// returns -1 if a is before b, 0 if they are equal and +1 if a is after b
+func cmpStart(a time.Time, b time.Time) int {
+	switch {
+	case startBefore(a, b):
+		return -1
+	case startBefore(b, a):
+		return 1
+	}
+	return 0
+}
+
+// cmpEnd compares two ends, a zero time being an infinite future.
+// returns -1 if a is before b, 0 if they are equal and +1 if a is after b
+func cmpEnd(a time.Time, b time.Time) int {
+	switch {
+	case endAfter(b, a):
+		return -1
+	case endAfter(a, b):
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,89 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRelation(t *testing.T) {
+	t0 := time.Date(2022, 6, 10, 0, 0, 0, 0, time.UTC)
+	day := func(from int, to int) TimeSlice {
+		return TimeSlice{From: t0.Add(time.Duration(from) * Day), To: t0.Add(time.Duration(to) * Day)}
+	}
+
+	tsa := day(10, 20)
+	cases := []struct {
+		tsb  TimeSlice
+		want Relation
+	}{
+		{day(25, 30), REL_BEFORE},
+		{day(20, 30), REL_MEETS},
+		{day(15, 30), REL_OVERLAPS},
+		{day(10, 30), REL_STARTS},
+		{day(5, 30), REL_DURING},
+		{day(5, 20), REL_FINISHES},
+		{day(10, 20), REL_EQUALS},
+		{day(15, 20), REL_FINISHED_BY},
+		{day(12, 18), REL_CONTAINS},
+		{day(10, 15), REL_STARTED_BY},
+		{day(5, 15), REL_OVERLAPPED_BY},
+		{day(5, 10), REL_MET_BY},
+		{day(0, 5), REL_AFTER},
+		// antichronological timeslices
+		{day(30, 25), REL_BEFORE},
+		{day(18, 12), REL_CONTAINS},
+		// infinite boundaries
+		{TimeSlice{From: t0.Add(25 * Day)}, REL_BEFORE},
+		{TimeSlice{From: t0.Add(10 * Day)}, REL_STARTS},
+		{TimeSlice{From: t0.Add(15 * Day)}, REL_OVERLAPS},
+		{TimeSlice{To: t0.Add(20 * Day)}, REL_FINISHES},
+		{TimeSlice{To: t0.Add(10 * Day)}, REL_MET_BY},
+		{TimeSlice{}, REL_DURING},
+	}
+	for _, c := range cases {
+		if got := tsa.Relation(c.tsb); got != c.want {
+			t.Errorf("Relation with %v fails: want %s got %s", c.tsb, c.want, got)
+		}
+		if got := c.tsb.Relation(tsa); got != c.want.Inverse() {
+			t.Errorf("inverse Relation with %v fails: want %s got %s", c.tsb, c.want.Inverse(), got)
+		}
+		anti := tsa
+		anti.ForceDirection(AntiChronological)
+		if got := anti.Relation(c.tsb); got != c.want {
+			t.Errorf("antichrono Relation with %v fails: want %s got %s", c.tsb, c.want, got)
+		}
+	}
+
+	// infinite both sides
+	if got := (TimeSlice{To: t0}).Relation(TimeSlice{To: t0}); got != REL_EQUALS {
+		t.Errorf("Relation infinite fails: got %s", got)
+	}
+	if got := (TimeSlice{}).Relation(TimeSlice{}); got != REL_EQUALS {
+		t.Errorf("Relation zero fails: got %s", got)
+	}
+
+	// single dates
+	if got := day(10, 10).Relation(tsa); got != REL_STARTS {
+		t.Errorf("Relation single date fails: got %s", got)
+	}
+	if got := day(15, 15).Relation(tsa); got != REL_DURING {
+		t.Errorf("Relation single date fails: got %s", got)
+	}
+}
+
+func ExampleTimeSlice_Relation() {
+	ts := MakeTimeSlice(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), Week)
+
+	fmt.Println(ts.Relation(MakeTimeSlice(ts.To, Day)))
+	fmt.Println(ts.Relation(MakeTimeSlice(ts.From.Add(Day), Day)))
+	fmt.Println(ts.Relation(TimeSlice{To: ts.To}))
+
+	// Output:
+	// MEETS
+	// CONTAINS
+	// FINISHES
+}
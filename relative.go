@@ -0,0 +1,233 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RelativeTime is a time relative to a reference time, like "now-7d/d" for the begining of the day 7 days before now.
+type RelativeTime struct {
+	Offset Period   // added to the reference time, months are clamped to the last day of the target month
+	Round  TimeMask // the unit the time is rounded to after the offset, MASK_NONE without rounding
+}
+
+// RelativeRange is a timeslice relative to a reference time, like "now-1M/M to now/M" from the begining of the former month to the end of the current month.
+type RelativeRange struct {
+	From RelativeTime // rounded down to the begining of its unit
+	To   RelativeTime // rounded up to the end of its unit
+}
+
+// relativeUnits are the units of relative times, with their rounding mask
+var relativeUnits = []struct {
+	unit string
+	mask TimeMask
+}{{"y", MASK_YEAR}, {"Q", MASK_QUARTER}, {"M", MASK_MONTH}, {"w", MASK_WEEK}, {"d", MASK_DAY}, {"h", MASK_HOUR}, {"m", MASK_MINUTE}, {"s", MASK_SECOND}}
+
+// ParseRelativeTime parses a relative time like "now", "now-24h", "now/d" or "now-1M+2d/M".
+//
+// The expression starts with "now", followed by any number of offsets like "-7d" or "+2h", and an optional rounding unit like "/d".
+// Units are y for years, Q for quarters, M for months, w for weeks, d for days, h for hours, m for minutes and s for seconds.
+// Weeks are rounded to ISO weeks starting on Monday.
+func ParseRelativeTime(str string) (rt RelativeTime, err error) {
+	rest, found := strings.CutPrefix(str, "now")
+	if !found {
+		return RelativeTime{}, fmt.Errorf("invalid relative time: %q", str)
+	}
+	rest, round, hasround := strings.Cut(rest, "/")
+	if hasround {
+		if rt.Round = relativeMask(round); rt.Round == MASK_NONE {
+			return RelativeTime{}, fmt.Errorf("invalid relative time: %q", str)
+		}
+	}
+	rt.Offset.MonthEnd = MONTHEND_CLAMP
+	for rest != "" {
+		// an offset is a sign, a number and a unit
+		i := strings.IndexFunc(rest[1:], func(r rune) bool { return r < '0' || r > '9' }) + 1
+		if (rest[0] != '+' && rest[0] != '-') || i < 2 {
+			return RelativeTime{}, fmt.Errorf("invalid relative time: %q", str)
+		}
+		n, err := strconv.Atoi(rest[:i])
+		if err != nil || !rt.Offset.add(n, rest[i:i+1]) {
+			return RelativeTime{}, fmt.Errorf("invalid relative time: %q", str)
+		}
+		rest = rest[i+1:]
+	}
+	return rt, nil
+}
+
+// ParseRelativeRange parses a relative range like "now-7d/d to now", "now-1M/M to now/M", or a single relative time:
+//   - with a rounding unit, the range is the whole unit, like "now/d" for today, or "now-1Q/Q" for the former quarter.
+//   - without rounding, the range ends now, like "now-24h" for the last 24 hours.
+//
+// The following names are also accepted, for a unit among second, minute, hour, day, week, month, quarter and year:
+//
+//	today, yesterday, tomorrow      like "now/d", "now-1d/d" and "now+1d/d"
+//	this <unit>                     like "this month" for "now/M"
+//	last <unit>, previous <unit>    like "last quarter" for "now-1Q/Q"
+//	next <unit>                     like "next week" for "now+1w/w"
+//	last <n> <unit>s                like "last 7 days" for "now-7d"
+//
+// See ParseRelativeTime for the syntax of relative times.
+func ParseRelativeRange(str string) (rr RelativeRange, err error) {
+	str = strings.TrimSpace(str)
+	if from, to, found := strings.Cut(str, " to "); found {
+		if rr.From, err = ParseRelativeTime(strings.TrimSpace(from)); err != nil {
+			return RelativeRange{}, err
+		}
+		if rr.To, err = ParseRelativeTime(strings.TrimSpace(to)); err != nil {
+			return RelativeRange{}, err
+		}
+		return rr, nil
+	}
+
+	if strings.HasPrefix(str, "now") {
+		rt, err := ParseRelativeTime(str)
+		if err != nil {
+			return RelativeRange{}, err
+		}
+		if rt.Round == MASK_NONE {
+			return RelativeRange{From: rt, To: RelativeTime{Offset: Period{MonthEnd: MONTHEND_CLAMP}}}, nil
+		}
+		return RelativeRange{From: rt, To: rt}, nil
+	}
+
+	expr, err := parseRelativeName(str)
+	if err != nil {
+		return RelativeRange{}, err
+	}
+	return ParseRelativeRange(expr)
+}
+
+// Time returns the relative time at the reference time now, in the location of now.
+// The time is rounded down to the begining of its unit, or up to the end of its unit, the begining of the next one, if end is true.
+func (rt RelativeTime) Time(now time.Time, end bool) time.Time {
+	t := rt.Offset.AddTo(now)
+	switch {
+	case rt.Round == MASK_NONE:
+	case end:
+		t = rt.Round.Add(t)
+	default:
+		t, _ = rt.Round.Apply(t)
+	}
+	return t
+}
+
+// String returns the relative time like "now-7d/d". Offsets are output from years to seconds, weeks as days and quarters as months.
+func (rt RelativeTime) String() string {
+	str := "now"
+	components := []struct {
+		v    int
+		unit string
+	}{{rt.Offset.Years, "y"}, {rt.Offset.Months, "M"}, {rt.Offset.Days, "d"}, {rt.Offset.Hours, "h"}, {rt.Offset.Minutes, "m"}, {rt.Offset.Seconds, "s"}}
+	for _, c := range components {
+		if c.v != 0 {
+			str += fmt.Sprintf("%+d%s", c.v, c.unit)
+		}
+	}
+	for _, ru := range relativeUnits {
+		if ru.mask == rt.Round {
+			str += "/" + ru.unit
+		}
+	}
+	return str
+}
+
+// TimeSlice returns the timeslice of the range at the reference time now, in the location of now.
+// The timeslice is anti-chronological if From is after To.
+func (rr RelativeRange) TimeSlice(now time.Time) TimeSlice {
+	return TimeSlice{From: rr.From.Time(now, false), To: rr.To.Time(now, true)}
+}
+
+// String returns the range like "now-7d/d to now", or a single relative time if it can be parsed back into the same range, like "now/d" or "now-24h".
+// Names like "last quarter" are output as relative times like "now-3M/Q".
+func (rr RelativeRange) String() string {
+	from, to := rr.From.String(), rr.To.String()
+	if (from == to && rr.From.Round != MASK_NONE) || (to == "now" && rr.From.Round == MASK_NONE) {
+		return from
+	}
+	return from + " to " + to
+}
+
+// add adds n units to the period, and returns false if the unit is unknown
+func (p *Period) add(n int, unit string) bool {
+	switch unit {
+	case "y":
+		p.Years += n
+	case "Q":
+		p.Months += 3 * n
+	case "M":
+		p.Months += n
+	case "w":
+		p.Days += 7 * n
+	case "d":
+		p.Days += n
+	case "h":
+		p.Hours += n
+	case "m":
+		p.Minutes += n
+	case "s":
+		p.Seconds += n
+	default:
+		return false
+	}
+	return true
+}
+
+// relativeMask returns the mask of a unit like "d", or MASK_NONE if the unit is unknown
+func relativeMask(unit string) TimeMask {
+	for _, ru := range relativeUnits {
+		if ru.unit == unit {
+			return ru.mask
+		}
+	}
+	return MASK_NONE
+}
+
+// parseRelativeName returns the relative expression of a name like "yesterday", "last quarter" or "last 7 days"
+func parseRelativeName(str string) (string, error) {
+	fields := strings.Fields(strings.ToLower(str))
+	switch {
+	case len(fields) == 1 && fields[0] == "today":
+		return "now/d", nil
+	case len(fields) == 1 && fields[0] == "yesterday":
+		return "now-1d/d", nil
+	case len(fields) == 1 && fields[0] == "tomorrow":
+		return "now+1d/d", nil
+	case len(fields) == 2:
+		unit := relativeUnit(fields[1])
+		if unit == "" {
+			break
+		}
+		switch fields[0] {
+		case "this":
+			return "now/" + unit, nil
+		case "last", "previous":
+			return "now-1" + unit + "/" + unit, nil
+		case "next":
+			return "now+1" + unit + "/" + unit, nil
+		}
+	case len(fields) == 3 && fields[0] == "last":
+		n, err := strconv.Atoi(fields[1])
+		unit := relativeUnit(strings.TrimSuffix(fields[2], "s"))
+		if err != nil || n < 1 || unit == "" {
+			break
+		}
+		return "now-" + strconv.Itoa(n) + unit, nil
+	}
+	return "", fmt.Errorf("invalid relative range: %q", str)
+}
+
+// relativeUnit returns the unit of a name like "day", or an empty string if the name is unknown
+func relativeUnit(name string) string {
+	for _, ru := range relativeUnits {
+		if ru.mask.String() == name {
+			return ru.unit
+		}
+	}
+	return ""
+}
@@ -0,0 +1,96 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestParseRelativeRange(t *testing.T) {
+	now := time.Date(2024, 5, 15, 14, 35, 20, 0, time.UTC) // a wednesday
+	date := func(M time.Month, d int, h int, m int, s int) time.Time {
+		return time.Date(2024, M, d, h, m, s, 0, time.UTC)
+	}
+	tests := []struct {
+		expr string
+		want TimeSlice
+		str  string
+	}{
+		{"now", TimeSlice{From: now, To: now}, "now"},
+		{"now-24h", TimeSlice{From: date(5, 14, 14, 35, 20), To: now}, "now-24h"},
+		{"now/d", TimeSlice{From: date(5, 15, 0, 0, 0), To: date(5, 16, 0, 0, 0)}, "now/d"},
+		{"now-7d/d to now", TimeSlice{From: date(5, 8, 0, 0, 0), To: now}, "now-7d/d to now"},
+		{"now-1M/M to now/M", TimeSlice{From: date(4, 1, 0, 0, 0), To: date(6, 1, 0, 0, 0)}, "now-1M/M to now/M"},
+		{"now-1M/M", TimeSlice{From: date(4, 1, 0, 0, 0), To: date(5, 1, 0, 0, 0)}, "now-1M/M"},
+		{"now-1y+2d-3h/h to now-30m", TimeSlice{From: time.Date(2023, 5, 17, 11, 0, 0, 0, time.UTC), To: date(5, 15, 14, 5, 20)}, "now-1y+2d-3h/h to now-30m"},
+		{"now-2w/w", TimeSlice{From: date(4, 29, 0, 0, 0), To: date(5, 6, 0, 0, 0)}, "now-14d/w"},
+		{"now/d to now-1d/d", TimeSlice{From: date(5, 15, 0, 0, 0), To: date(5, 15, 0, 0, 0)}, "now/d to now-1d/d"},
+		{"today", TimeSlice{From: date(5, 15, 0, 0, 0), To: date(5, 16, 0, 0, 0)}, "now/d"},
+		{"Yesterday", TimeSlice{From: date(5, 14, 0, 0, 0), To: date(5, 15, 0, 0, 0)}, "now-1d/d"},
+		{"this week", TimeSlice{From: date(5, 13, 0, 0, 0), To: date(5, 20, 0, 0, 0)}, "now/w"},
+		{"last quarter", TimeSlice{From: date(1, 1, 0, 0, 0), To: date(4, 1, 0, 0, 0)}, "now-3M/Q"},
+		{"next year", TimeSlice{From: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}, "now+1y/y"},
+		{"last 7 days", TimeSlice{From: date(5, 8, 14, 35, 20), To: now}, "now-7d"},
+		{"last 1 hour", TimeSlice{From: date(5, 15, 13, 35, 20), To: now}, "now-1h"},
+	}
+	for _, test := range tests {
+		rr, err := ParseRelativeRange(test.expr)
+		if err != nil {
+			t.Errorf("ParseRelativeRange %q fails: %v", test.expr, err)
+			continue
+		}
+		if got := rr.TimeSlice(now); !got.From.Equal(test.want.From) || !got.To.Equal(test.want.To) {
+			t.Errorf("ParseRelativeRange %q fails: want %v got %v", test.expr, test.want, got)
+		}
+		if got := rr.String(); got != test.str {
+			t.Errorf("String %q fails: want %q got %q", test.expr, test.str, got)
+		}
+		if back, err := ParseRelativeRange(rr.String()); err != nil || back.TimeSlice(now) != rr.TimeSlice(now) {
+			t.Errorf("ParseRelativeRange of String %q fails: %v", rr, err)
+		}
+	}
+
+	invalids := []string{"", "then", "now-", "now-7", "now-7x", "now-d", "now--7d", "now/", "now/x", "now/d/d", "now-7d/d to", "to now",
+		"now to now to now", "yesterday-1d", "last -1 days", "last 0 days", "last decade", "this"}
+	for _, expr := range invalids {
+		if _, err := ParseRelativeRange(expr); err == nil {
+			t.Errorf("ParseRelativeRange %q fails: want an error", expr)
+		}
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	// months are clamped to the last day of the target month
+	rt, _ := ParseRelativeTime("now-1M")
+	if got, want := rt.Time(time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC), false), time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Time fails: want %v got %v", want, got)
+	}
+
+	// rounding in the location of now, across a daylight saving time change
+	paris, _ := time.LoadLocation("Europe/Paris")
+	rt, _ = ParseRelativeTime("now/d")
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, paris)
+	if got, want := rt.Time(now, false), time.Date(2024, 3, 30, 23, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Time fails: want %v got %v", want, got)
+	}
+	if got, want := rt.Time(now, true), time.Date(2024, 3, 31, 22, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Time end fails: want %v got %v", want, got)
+	}
+}
+
+func ExampleParseRelativeRange() {
+	now := time.Date(2024, 5, 15, 14, 35, 20, 0, time.UTC)
+	for _, expr := range []string{"now-24h", "now-7d/d to now", "now-1M/M to now/M", "last quarter"} {
+		rr, _ := ParseRelativeRange(expr)
+		fmt.Printf("%-18s %s\n", rr, rr.TimeSlice(now))
+	}
+
+	// Output:
+	// now-24h            { 20240514 14:35:20 UTC - 20240515 14:35:20 UTC : 1d }
+	// now-7d/d to now    { 20240508 UTC - 20240515 14:35:20 UTC : 7d14h35m~ }
+	// now-1M/M to now/M  { 20240401 UTC - 20240601 UTC : 2M3h }
+	// now-3M/Q           { 20240101 UTC - 20240401 UTC : 2M30d3h }
+}
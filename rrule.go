@@ -0,0 +1,539 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency of a recurrence rule
+type Frequency int
+
+const (
+	FREQ_DAILY   Frequency = 1
+	FREQ_WEEKLY  Frequency = 2
+	FREQ_MONTHLY Frequency = 3
+	FREQ_YEARLY  Frequency = 4
+)
+
+func (freq Frequency) String() string {
+	switch freq {
+	case FREQ_DAILY:
+		return "DAILY"
+	case FREQ_WEEKLY:
+		return "WEEKLY"
+	case FREQ_MONTHLY:
+		return "MONTHLY"
+	case FREQ_YEARLY:
+		return "YEARLY"
+	}
+	return "?"
+}
+
+// WeekdayNum is a BYDAY element of a recurrence rule, a weekday with an optional ordinal within the month or the year.
+//
+//	N == 0 means every weekday of the period
+//	N > 0 means the Nth weekday of the period, 1 for the first one
+//	N < 0 means the Nth weekday from the end of the period, -1 for the last one
+type WeekdayNum struct {
+	Weekday time.Weekday
+	N       int
+}
+
+// RRule is a recurrence rule, as defined by the RFC 5545, generating timeslices.
+//
+// The following subset of the RFC is supported: FREQ (DAILY, WEEKLY, MONTHLY and YEARLY), INTERVAL, COUNT, UNTIL, WKST,
+// BYDAY, BYMONTHDAY, BYMONTH, BYSETPOS, and EXDATE.
+//
+// All occurrences start at the time of the day of DTStart, in the location of DTStart, even across daylight saving time changes.
+type RRule struct {
+	DTStart    time.Time     // the first occurrence, required
+	Duration   time.Duration // the duration of every occurrence
+	Freq       Frequency     // required
+	Interval   int           // 1 if not defined
+	Count      int           // 0 if not defined
+	Until      time.Time     // inclusive, zero if not defined
+	WeekStart  time.Weekday  // the first day of the week, Monday by default
+	ByDay      []WeekdayNum  //
+	ByMonthDay []int         // days of the month from 1 to 31, or from -31 to -1 from the end of the month
+	ByMonth    []time.Month  //
+	BySetPos   []int         // positions within the occurrences of every period, from 1 or from -1 from the end of the period
+	ExDates    []time.Time   // excluded occurrences
+}
+
+// maxEmptyPeriods bounds the search when a rule does not produce any occurrence, like a 31st of February
+const maxEmptyPeriods = 10000
+
+var weekdays = map[string]time.Weekday{"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday}
+
+// ParseRRule parses a recurrence rule according to the RFC 5545.
+//
+// The text can be a single rule like "FREQ=WEEKLY;BYDAY=MO,TH;UNTIL=20241231T235959Z", or several lines with
+// the following properties:
+//
+//	DTSTART;TZID=Europe/Paris:20240101T090000
+//	DTEND;TZID=Europe/Paris:20240101T103000
+//	RRULE:FREQ=WEEKLY;BYDAY=MO,TH;UNTIL=20241231T235959Z
+//	EXDATE;TZID=Europe/Paris:20240101T090000,20240104T090000
+//
+// DTSTART without TZID and without the 'Z' suffix is in UTC. UNTIL and EXDATE without TZID and without the 'Z' suffix are in the location of DTSTART.
+// A date without time is midnight, except for UNTIL where the whole day is included.
+func ParseRRule(text string) (rule RRule, err error) {
+	// property values and their TZID, to be parsed once the location of DTSTART is known
+	type tzvalue struct{ value, tzid string }
+	var rrule string
+	var dtend *tzvalue
+	var exdates []tzvalue
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		name, value, found := strings.Cut(line, ":")
+		if !found {
+			if strings.HasPrefix(strings.ToUpper(line), "FREQ=") {
+				rrule = line
+				continue
+			}
+			return RRule{}, fmt.Errorf("invalid rrule line: %q", line)
+		}
+		params := strings.Split(name, ";")
+		name = strings.ToUpper(params[0])
+		var tzid string
+		for _, param := range params[1:] {
+			if k, v, _ := strings.Cut(param, "="); strings.ToUpper(k) == "TZID" {
+				tzid = v
+			}
+		}
+		switch name {
+		case "DTSTART":
+			if rule.DTStart, err = parseRRuleTimeIn(value, tzid, time.UTC, false); err != nil {
+				return RRule{}, err
+			}
+		case "DTEND":
+			dtend = &tzvalue{value, tzid}
+		case "RRULE":
+			rrule = value
+		case "EXDATE":
+			for _, v := range strings.Split(value, ",") {
+				exdates = append(exdates, tzvalue{v, tzid})
+			}
+		default:
+			return RRule{}, fmt.Errorf("unsupported rrule property: %q", name)
+		}
+	}
+
+	loc := time.UTC
+	if !rule.DTStart.IsZero() {
+		loc = rule.DTStart.Location()
+	}
+	if err = rule.parseRule(rrule, loc); err != nil {
+		return RRule{}, err
+	}
+	if dtend != nil {
+		end, err := parseRRuleTimeIn(dtend.value, dtend.tzid, loc, false)
+		if err != nil {
+			return RRule{}, err
+		}
+		rule.Duration = end.Sub(rule.DTStart)
+	}
+	for _, exdate := range exdates {
+		ex, err := parseRRuleTimeIn(exdate.value, exdate.tzid, loc, false)
+		if err != nil {
+			return RRule{}, err
+		}
+		rule.ExDates = append(rule.ExDates, ex)
+	}
+	return rule, nil
+}
+
+// parseRule parses the "FREQ=...;..." part of a recurrence rule
+func (rule *RRule) parseRule(rrule string, loc *time.Location) (err error) {
+	if rrule == "" {
+		return errors.New("missing RRULE")
+	}
+	rule.Interval = 1
+	rule.WeekStart = time.Monday
+	for _, part := range strings.Split(strings.ToUpper(rrule), ";") {
+		key, value, found := strings.Cut(part, "=")
+		if !found {
+			return fmt.Errorf("invalid rrule part: %q", part)
+		}
+		switch key {
+		case "FREQ":
+			switch value {
+			case "DAILY":
+				rule.Freq = FREQ_DAILY
+			case "WEEKLY":
+				rule.Freq = FREQ_WEEKLY
+			case "MONTHLY":
+				rule.Freq = FREQ_MONTHLY
+			case "YEARLY":
+				rule.Freq = FREQ_YEARLY
+			default:
+				return fmt.Errorf("unsupported rrule frequency: %q", value)
+			}
+		case "INTERVAL":
+			if rule.Interval, err = strconv.Atoi(value); err != nil || rule.Interval < 1 {
+				return fmt.Errorf("invalid rrule interval: %q", value)
+			}
+		case "COUNT":
+			if rule.Count, err = strconv.Atoi(value); err != nil || rule.Count < 1 {
+				return fmt.Errorf("invalid rrule count: %q", value)
+			}
+		case "UNTIL":
+			if rule.Until, err = parseRRuleTime(value, loc, true); err != nil {
+				return err
+			}
+		case "WKST":
+			wd, found := weekdays[value]
+			if !found {
+				return fmt.Errorf("invalid rrule week start: %q", value)
+			}
+			rule.WeekStart = wd
+		case "BYDAY":
+			for _, v := range strings.Split(value, ",") {
+				if len(v) < 2 {
+					return fmt.Errorf("invalid rrule day: %q", v)
+				}
+				wd, found := weekdays[v[len(v)-2:]]
+				if !found {
+					return fmt.Errorf("invalid rrule day: %q", v)
+				}
+				wdn := WeekdayNum{Weekday: wd}
+				if len(v) > 2 {
+					if wdn.N, err = strconv.Atoi(v[:len(v)-2]); err != nil || wdn.N == 0 || wdn.N < -53 || wdn.N > 53 {
+						return fmt.Errorf("invalid rrule day: %q", v)
+					}
+				}
+				rule.ByDay = append(rule.ByDay, wdn)
+			}
+		case "BYMONTHDAY":
+			if rule.ByMonthDay, err = parseRRuleInts(value, 31); err != nil {
+				return err
+			}
+		case "BYMONTH":
+			months, err := parseRRuleInts(value, 12)
+			if err != nil {
+				return err
+			}
+			for _, m := range months {
+				if m < 0 {
+					return fmt.Errorf("invalid rrule month: %d", m)
+				}
+				rule.ByMonth = append(rule.ByMonth, time.Month(m))
+			}
+		case "BYSETPOS":
+			if rule.BySetPos, err = parseRRuleInts(value, 366); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported rrule part: %q", key)
+		}
+	}
+	if rule.Freq == 0 {
+		return errors.New("missing rrule frequency")
+	}
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return errors.New("rrule can not have both COUNT and UNTIL")
+	}
+	return nil
+}
+
+// parseRRuleInts parses a list of non-zero integers bounded between -max and max
+func parseRRuleInts(value string, max int) ([]int, error) {
+	ints := make([]int, 0)
+	for _, v := range strings.Split(value, ",") {
+		i, err := strconv.Atoi(v)
+		if err != nil || i == 0 || i < -max || i > max {
+			return nil, fmt.Errorf("invalid rrule value: %q", v)
+		}
+		ints = append(ints, i)
+	}
+	return ints, nil
+}
+
+// parseRRuleTimeIn parses a date or a datetime in the tzid location, or in loc if tzid is empty
+func parseRRuleTimeIn(value string, tzid string, loc *time.Location, endofday bool) (time.Time, error) {
+	if tzid != "" {
+		var err error
+		if loc, err = time.LoadLocation(tzid); err != nil {
+			return time.Time{}, err
+		}
+	}
+	return parseRRuleTime(value, loc, endofday)
+}
+
+// parseRRuleTime parses a date or a datetime in loc, unless it's an UTC datetime.
+// A date is midnight, or the last nanosecond of the day if endofday is requested.
+func parseRRuleTime(value string, loc *time.Location, endofday bool) (t time.Time, err error) {
+	switch {
+	case strings.HasSuffix(value, "Z"):
+		t, err = time.Parse("20060102T150405Z", value)
+	case strings.Contains(value, "T"):
+		t, err = time.ParseInLocation("20060102T150405", value, loc)
+	default:
+		t, err = time.ParseInLocation("20060102", value, loc)
+		if err == nil && endofday {
+			t = time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 999999999, loc)
+		}
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid rrule time: %q", value)
+	}
+	return t, nil
+}
+
+// Occurrences returns all the occurrences of the rule starting within the timeslice, sorted chronologically.
+//
+// Occurrences start from DTStart, so an infinite begining of the timeslice starts at DTStart.
+//
+// returns an error if DTStart is not defined, or if the timeslice has an infinite end and the rule has neither COUNT nor UNTIL.
+func (rule RRule) Occurrences(within TimeSlice) ([]TimeSlice, error) {
+	if rule.DTStart.IsZero() {
+		return nil, errors.New("missing rrule DTSTART")
+	}
+	within.ForceDirection(Chronological)
+	if within.To.IsZero() && rule.Count == 0 && rule.Until.IsZero() {
+		return nil, fmt.Errorf("unable to expand an infinite rrule within an infinite timeslice: %w", ErrInfinite)
+	}
+
+	// the last possible start
+	limit := within.To
+	if !rule.Until.IsZero() && (limit.IsZero() || rule.Until.Before(limit)) {
+		limit = rule.Until
+	}
+
+	occurrences := make([]TimeSlice, 0)
+	count := 0
+	empty := 0
+	for period := 0; empty < maxEmptyPeriods; period++ {
+		starts, periodstart := rule.expand(period)
+		if !limit.IsZero() && periodstart.After(limit) {
+			break
+		}
+		if len(starts) == 0 {
+			empty++
+			continue
+		}
+		empty = 0
+		for _, start := range starts {
+			if start.Before(rule.DTStart) {
+				continue
+			}
+			if !limit.IsZero() && start.After(limit) {
+				return occurrences, nil
+			}
+			count++
+			if rule.Count > 0 && count > rule.Count {
+				return occurrences, nil
+			}
+			if rule.isExcluded(start) || (!within.From.IsZero() && start.Before(within.From)) {
+				continue
+			}
+			occurrences = append(occurrences, MakeTimeSlice(start, rule.Duration))
+		}
+	}
+	return occurrences, nil
+}
+
+func (rule RRule) isExcluded(start time.Time) bool {
+	for _, ex := range rule.ExDates {
+		if ex.Equal(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// expand returns the sorted starts of the occurrences of the nth period, before applying DTStart, COUNT, UNTIL and EXDATE.
+// Returns also the begining of the period.
+func (rule RRule) expand(period int) (starts []time.Time, periodstart time.Time) {
+	dt := rule.DTStart
+	loc := dt.Location()
+	step := period * max(rule.Interval, 1)
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, dt.Hour(), dt.Minute(), dt.Second(), dt.Nanosecond(), loc)
+	}
+
+	days := make([]time.Time, 0)
+	switch rule.Freq {
+	case FREQ_DAILY:
+		day := time.Date(dt.Year(), dt.Month(), dt.Day()+step, 0, 0, 0, 0, loc)
+		periodstart = day
+		if rule.matchDay(day) {
+			days = append(days, day)
+		}
+
+	case FREQ_WEEKLY:
+		shift := (int(dt.Weekday()) - int(rule.WeekStart) + 7) % 7
+		weekstart := time.Date(dt.Year(), dt.Month(), dt.Day()-shift+7*step, 0, 0, 0, 0, loc)
+		periodstart = weekstart
+		for i := 0; i < 7; i++ {
+			day := time.Date(weekstart.Year(), weekstart.Month(), weekstart.Day()+i, 0, 0, 0, 0, loc)
+			if len(rule.ByDay) == 0 && day.Weekday() != dt.Weekday() {
+				continue
+			}
+			if rule.matchDay(day) {
+				days = append(days, day)
+			}
+		}
+
+	case FREQ_MONTHLY:
+		monthstart := time.Date(dt.Year(), dt.Month()+time.Month(step), 1, 0, 0, 0, 0, loc)
+		periodstart = monthstart
+		if rule.matchMonth(monthstart.Month()) {
+			days = rule.monthDays(monthstart.Year(), monthstart.Month(), dt.Day())
+		}
+
+	case FREQ_YEARLY:
+		y := dt.Year() + step
+		periodstart = time.Date(y, 1, 1, 0, 0, 0, 0, loc)
+		switch {
+		case len(rule.ByMonth) == 0 && len(rule.ByMonthDay) == 0 && len(rule.ByDay) > 0:
+			// weekdays ordinals are within the year
+			for d := periodstart; d.Year() == y; d = time.Date(y, 1, d.YearDay()+1, 0, 0, 0, 0, loc) {
+				if rule.matchWeekday(d, time.Date(y, 1, 1, 0, 0, 0, 0, loc), time.Date(y, 12, 31, 0, 0, 0, 0, loc)) {
+					days = append(days, d)
+				}
+			}
+		case len(rule.ByMonth) == 0 && len(rule.ByMonthDay) == 0:
+			if dt.Day() <= daysIn(y, dt.Month()) {
+				days = append(days, time.Date(y, dt.Month(), dt.Day(), 0, 0, 0, 0, loc))
+			}
+		default:
+			for m := time.January; m <= time.December; m++ {
+				if rule.matchMonth(m) {
+					days = append(days, rule.monthDays(y, m, dt.Day())...)
+				}
+			}
+		}
+	}
+
+	starts = make([]time.Time, 0, len(days))
+	for _, day := range days {
+		starts = append(starts, at(day.Year(), day.Month(), day.Day()))
+	}
+	return rule.applySetPos(starts), periodstart
+}
+
+// monthDays returns the days of the month matching BYMONTHDAY and BYDAY, or the day dtday if none of them are defined
+func (rule RRule) monthDays(y int, m time.Month, dtday int) []time.Time {
+	loc := rule.DTStart.Location()
+	n := daysIn(y, m)
+	days := make([]time.Time, 0)
+	if len(rule.ByMonthDay) == 0 && len(rule.ByDay) == 0 {
+		if dtday <= n {
+			days = append(days, time.Date(y, m, dtday, 0, 0, 0, 0, loc))
+		}
+		return days
+	}
+	first := time.Date(y, m, 1, 0, 0, 0, 0, loc)
+	last := time.Date(y, m, n, 0, 0, 0, 0, loc)
+	for d := 1; d <= n; d++ {
+		day := time.Date(y, m, d, 0, 0, 0, 0, loc)
+		if rule.matchMonthDay(d, n) && (len(rule.ByDay) == 0 || rule.matchWeekday(day, first, last)) {
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+// matchDay checks a day against BYMONTH, BYMONTHDAY and BYDAY, ignoring BYDAY ordinals
+func (rule RRule) matchDay(day time.Time) bool {
+	if !rule.matchMonth(day.Month()) {
+		return false
+	}
+	if len(rule.ByMonthDay) > 0 && !rule.matchMonthDay(day.Day(), daysIn(day.Year(), day.Month())) {
+		return false
+	}
+	if len(rule.ByDay) > 0 {
+		for _, wdn := range rule.ByDay {
+			if wdn.Weekday == day.Weekday() {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func (rule RRule) matchMonth(m time.Month) bool {
+	if len(rule.ByMonth) == 0 {
+		return true
+	}
+	for _, bym := range rule.ByMonth {
+		if bym == m {
+			return true
+		}
+	}
+	return false
+}
+
+func (rule RRule) matchMonthDay(d int, ndays int) bool {
+	if len(rule.ByMonthDay) == 0 {
+		return true
+	}
+	for _, bymd := range rule.ByMonthDay {
+		if bymd == d || (bymd < 0 && ndays+bymd+1 == d) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchWeekday checks a day against BYDAY with ordinals counted between first and last days
+func (rule RRule) matchWeekday(day time.Time, first time.Time, last time.Time) bool {
+	for _, wdn := range rule.ByDay {
+		if wdn.Weekday != day.Weekday() {
+			continue
+		}
+		switch {
+		case wdn.N == 0:
+			return true
+		case wdn.N > 0 && (day.YearDay()-first.YearDay())/7+1 == wdn.N:
+			return true
+		case wdn.N < 0 && (last.YearDay()-day.YearDay())/7+1 == -wdn.N:
+			return true
+		}
+	}
+	return false
+}
+
+// applySetPos keeps only the sorted times at BYSETPOS positions
+func (rule RRule) applySetPos(starts []time.Time) []time.Time {
+	if len(rule.BySetPos) == 0 || len(starts) == 0 {
+		return starts
+	}
+	selected := make([]time.Time, 0, len(rule.BySetPos))
+	for _, pos := range rule.BySetPos {
+		i := pos - 1
+		if pos < 0 {
+			i = len(starts) + pos
+		}
+		if i < 0 || i >= len(starts) {
+			continue
+		}
+		dup := false
+		for _, s := range selected {
+			dup = dup || s.Equal(starts[i])
+		}
+		if !dup {
+			selected = append(selected, starts[i])
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Before(selected[j]) })
+	return selected
+}
+
+// daysIn returns the number of days of the month m in the year y
+func daysIn(y int, m time.Month) int {
+	return time.Date(y, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
@@ -0,0 +1,158 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRRuleWeekly(t *testing.T) {
+	rule, err := ParseRRule(`
+		DTSTART;TZID=Europe/Paris:20240101T090000
+		DTEND;TZID=Europe/Paris:20240101T103000
+		RRULE:FREQ=WEEKLY;BYDAY=MO,TH;UNTIL=20241231T235959Z
+		EXDATE;TZID=Europe/Paris:20240104T090000`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paris := rule.DTStart.Location()
+
+	// the whole year
+	all, err := rule.Occurrences(TimeSlice{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2024 has 53 mondays and 52 thursdays, minus one excluded date
+	if len(all) != 104 {
+		t.Errorf("Occurrences fails: want 104 got %d", len(all))
+	}
+	for _, ts := range all {
+		local := ts.From.In(paris)
+		if local.Hour() != 9 || local.Minute() != 0 || ts.Duration().Duration != 90*time.Minute {
+			t.Errorf("Occurrences fails, wrong time: %v", local)
+		}
+		if local.Weekday() != time.Monday && local.Weekday() != time.Thursday {
+			t.Errorf("Occurrences fails, wrong day: %v", local)
+		}
+	}
+
+	// within a window across the spring daylight saving time change
+	within := TimeSlice{From: time.Date(2024, 3, 28, 0, 0, 0, 0, paris), To: time.Date(2024, 4, 5, 0, 0, 0, 0, paris)}
+	occs, err := rule.Occurrences(within)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []time.Time{
+		time.Date(2024, 3, 28, 8, 0, 0, 0, time.UTC),
+		time.Date(2024, 4, 1, 7, 0, 0, 0, time.UTC),
+		time.Date(2024, 4, 4, 7, 0, 0, 0, time.UTC),
+	}
+	if len(occs) != len(want) {
+		t.Fatalf("Occurrences within fails: got %v", occs)
+	}
+	for i := range want {
+		if !occs[i].From.Equal(want[i]) {
+			t.Errorf("Occurrences within fails: want %v got %v", want[i], occs[i])
+		}
+	}
+}
+
+func TestRRuleMonthlyYearly(t *testing.T) {
+	dtstart := time.Date(2024, 1, 1, 18, 0, 0, 0, time.UTC)
+	cases := []struct {
+		rule string
+		want []string
+	}{
+		// last friday of the month
+		{"FREQ=MONTHLY;BYDAY=-1FR;COUNT=3", []string{"2024-01-26", "2024-02-23", "2024-03-29"}},
+		// last working day of the month
+		{"FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1;COUNT=4", []string{"2024-01-31", "2024-02-29", "2024-03-29", "2024-04-30"}},
+		// the 31st, skipping shorter months
+		{"FREQ=MONTHLY;BYMONTHDAY=31;COUNT=3", []string{"2024-01-31", "2024-03-31", "2024-05-31"}},
+		// every other month, the first and the last day
+		{"FREQ=MONTHLY;INTERVAL=2;BYMONTHDAY=1,-1;COUNT=4", []string{"2024-01-01", "2024-01-31", "2024-03-01", "2024-03-31"}},
+		// leap days
+		{"FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29;COUNT=3", []string{"2024-02-29", "2028-02-29", "2032-02-29"}},
+		// thanksgiving
+		{"FREQ=YEARLY;BYMONTH=11;BYDAY=4TH;COUNT=2", []string{"2024-11-28", "2025-11-27"}},
+		// the 20th monday of the year
+		{"FREQ=YEARLY;BYDAY=20MO;COUNT=2", []string{"2024-05-13", "2025-05-19"}},
+		// every 3 days until a date
+		{"FREQ=DAILY;INTERVAL=3;UNTIL=20240110", []string{"2024-01-01", "2024-01-04", "2024-01-07", "2024-01-10"}},
+		// every other sunday, with weeks starting on sundays, so the first week starts before DTSTART
+		{"FREQ=WEEKLY;INTERVAL=2;WKST=SU;BYDAY=SU;COUNT=2", []string{"2024-01-14", "2024-01-28"}},
+	}
+	for _, c := range cases {
+		rule, err := ParseRRule(c.rule)
+		if err != nil {
+			t.Errorf("ParseRRule %q fails: %v", c.rule, err)
+			continue
+		}
+		rule.DTStart = dtstart
+		occs, err := rule.Occurrences(TimeSlice{})
+		if err != nil {
+			t.Errorf("Occurrences %q fails: %v", c.rule, err)
+			continue
+		}
+		got := make([]string, len(occs))
+		for i, ts := range occs {
+			got[i] = ts.From.Format("2006-01-02")
+		}
+		if fmt.Sprint(got) != fmt.Sprint(c.want) {
+			t.Errorf("Occurrences %q fails: want %v got %v", c.rule, c.want, got)
+		}
+	}
+}
+
+func TestRRuleErrors(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;COUNT=2;UNTIL=20240101",
+		"FREQ=DAILY;BYMONTH=13",
+		"FREQ=DAILY;BYDAY=XX",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;BYWEEKNO=1",
+		"DTSTART;TZID=Nowhere/Land:20240101T090000\nRRULE:FREQ=DAILY",
+	} {
+		if _, err := ParseRRule(text); err == nil {
+			t.Errorf("ParseRRule %q fails: want an error", text)
+		}
+	}
+
+	rule, err := ParseRRule("FREQ=DAILY")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rule.Occurrences(TimeSlice{}); err == nil {
+		t.Error("Occurrences fails: want an error without DTSTART")
+	}
+	rule.DTStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := rule.Occurrences(TimeSlice{From: rule.DTStart}); err == nil {
+		t.Error("Occurrences fails: want an error for an infinite rule")
+	}
+	if occs, err := rule.Occurrences(MakeTimeSlice(rule.DTStart.Add(Week), -Week)); err != nil || len(occs) != 8 {
+		t.Errorf("Occurrences fails with an antichronological timeslice: %v %v", occs, err)
+	}
+}
+
+func ExampleRRule_Occurrences() {
+	rule, _ := ParseRRule(`
+		DTSTART;TZID=Europe/Paris:20240101T090000
+		DTEND;TZID=Europe/Paris:20240101T103000
+		RRULE:FREQ=WEEKLY;BYDAY=MO,TH;UNTIL=20241231T235959Z`)
+
+	// the first week of march
+	within := MakeTimeSlice(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Week)
+	occs, _ := rule.Occurrences(within)
+	for _, ts := range occs {
+		fmt.Println(ts)
+	}
+
+	// Output:
+	// { 20240304 08:00:00 UTC - 09:30:00 UTC : 1h30m }
+	// { 20240307 08:00:00 UTC - 09:30:00 UTC : 1h30m }
+}
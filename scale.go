@@ -0,0 +1,110 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"math"
+	"time"
+)
+
+// Scale maps the times of a timeslice, the domain, onto a numeric range like pixels, and back.
+//
+// The begining of the domain is mapped to Min and its end to Max, so an anti-chronological domain gives an inverted scale,
+// with later times at lower values. Min can be greater than Max, like for a vertical axis with values going downward.
+type Scale struct {
+	Domain TimeSlice // the times to map, with finite boundaries
+	Min    float64   // the value of the begining of the domain
+	Max    float64   // the value of the end of the domain
+	Clamp  bool      // bound values within the range, and times within the domain
+}
+
+// NewScale returns a scale mapping the domain onto the range [min, max].
+func NewScale(domain TimeSlice, min float64, max float64) Scale {
+	return Scale{Domain: domain, Min: min, Max: max}
+}
+
+// Position returns the value corresponding to t, with a nanosecond precision.
+// If Clamp is set, the returned value is bounded within the range.
+//
+// returns the middle of the range if the domain has an infinite boundary or is a single date.
+func (s Scale) Position(t time.Time) float64 {
+	dur := s.Domain.Duration()
+	if s.Domain.IsInfinite() || dur.Duration == 0 {
+		return (s.Min + s.Max) / 2
+	}
+	v := s.Min + float64(t.Sub(s.Domain.From))/float64(dur.Duration)*(s.Max-s.Min)
+	if s.Clamp {
+		v = math.Max(math.Min(s.Min, s.Max), math.Min(math.Max(s.Min, s.Max), v))
+	}
+	return v
+}
+
+// Time returns the time corresponding to the value v, rounded to the nanosecond.
+// If Clamp is set, the returned time is bounded within the domain.
+//
+// returns a zero time if the domain has an infinite boundary. If the domain is a single date or the range is empty then returns its begining.
+func (s Scale) Time(v float64) time.Time {
+	if s.Domain.IsInfinite() {
+		return time.Time{}
+	}
+	t := s.time(v)
+	if s.Clamp {
+		t = s.Domain.Bound(t)
+	}
+	return t
+}
+
+// time returns the time corresponding to the value v, without clamping
+func (s Scale) time(v float64) time.Time {
+	if s.Max == s.Min {
+		return s.Domain.From
+	}
+	dur := s.Domain.Duration()
+	return s.Domain.From.Add(time.Duration(math.Round((v - s.Min) / (s.Max - s.Min) * float64(dur.Duration))))
+}
+
+// ZoomAt zooms the domain by factor around the value v, the time at v stays at v.
+// A factor greater than 1 zooms in and shortens the domain, a factor lower than 1 zooms out.
+//
+// If bound is not zero, the zoomed domain is kept within bound, shifting it with ShiftIn, and zooming out stops at bound.
+//
+// Nothing occurs if factor <= 0, if the domain has an infinite boundary, or if the zoomed domain would be a single date.
+func (s *Scale) ZoomAt(v float64, factor float64, bound TimeSlice) *Scale {
+	if factor <= 0 || s.Domain.IsInfinite() {
+		return s
+	}
+	at := s.time(v)
+	domain := TimeSlice{
+		From: at.Add(time.Duration(math.Round(float64(s.Domain.From.Sub(at)) / factor))),
+		To:   at.Add(time.Duration(math.Round(float64(s.Domain.To.Sub(at)) / factor))),
+	}
+	if domain.From.Equal(domain.To) {
+		return s
+	}
+	if domain.ShiftIn(0, bound) == nil {
+		// the zoomed domain is longer than bound
+		domain = bound
+		domain.ForceDirection(s.Domain.Direction())
+	}
+	s.Domain = domain
+	return s
+}
+
+// Pan moves the domain so that times move by dv within the range, like when dragging a chart by dv pixels.
+//
+// If bound is not zero, the moved domain is kept within bound with ShiftIn.
+//
+// Nothing occurs if the domain has an infinite boundary, or if the domain is longer than bound.
+func (s *Scale) Pan(dv float64, bound TimeSlice) *Scale {
+	if s.Domain.IsInfinite() || s.Max == s.Min {
+		return s
+	}
+	shiftby := -time.Duration(math.Round(dv / (s.Max - s.Min) * float64(s.Domain.Duration().Duration)))
+	domain := s.Domain
+	if domain.ShiftIn(shiftby, bound) == nil {
+		return s
+	}
+	s.Domain = domain
+	return s
+}
@@ -0,0 +1,123 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestScale(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	chrono := MakeTimeSlice(t0, 10*time.Hour)
+	antichrono := MakeTimeSlice(t0.Add(10*time.Hour), -10*time.Hour)
+
+	cases := []struct {
+		scale Scale
+		t     time.Time
+		v     float64
+	}{
+		{NewScale(chrono, 0, 1000), t0, 0},
+		{NewScale(chrono, 0, 1000), t0.Add(2*time.Hour + 30*time.Minute), 250},
+		{NewScale(chrono, 0, 1000), t0.Add(10 * time.Hour), 1000},
+		{NewScale(chrono, 0, 1000), t0.Add(-time.Hour), -100},
+		{NewScale(chrono, 0, 1000), t0.Add(time.Nanosecond), 1000.0 / float64(10*time.Hour)},
+		{NewScale(chrono, 500, 100), t0.Add(5 * time.Hour), 300},
+		{NewScale(antichrono, 0, 1000), t0.Add(8 * time.Hour), 200},
+		{Scale{Domain: chrono, Min: 0, Max: 1000, Clamp: true}, t0.Add(-time.Hour), 0},
+		{Scale{Domain: chrono, Min: 1000, Max: 0, Clamp: true}, t0.Add(11 * time.Hour), 0},
+	}
+	for i, c := range cases {
+		if got := c.scale.Position(c.t); got != c.v {
+			t.Errorf("case %d: Position fails: want %v got %v", i, c.v, got)
+		}
+		if got := c.scale.Time(c.v); !c.scale.Clamp && !got.Equal(c.t) {
+			t.Errorf("case %d: Time fails: want %v got %v", i, c.t, got)
+		}
+	}
+
+	clamped := Scale{Domain: chrono, Min: 0, Max: 1000, Clamp: true}
+	if got := clamped.Time(1200); !got.Equal(chrono.To) {
+		t.Errorf("Time clamp fails: got %v", got)
+	}
+	if got := NewScale(TimeSlice{From: t0}, 0, 100).Position(t0); got != 50 {
+		t.Errorf("Position infinite fails: got %v", got)
+	}
+	if got := NewScale(TimeSlice{From: t0}, 0, 100).Time(50); !got.IsZero() {
+		t.Errorf("Time infinite fails: got %v", got)
+	}
+}
+
+func TestScaleZoomPan(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	bound := MakeTimeSlice(t0, 24*time.Hour)
+
+	// zoom in around the time at 200, which stays at 200
+	s := NewScale(MakeTimeSlice(t0, 8*time.Hour), 0, 800)
+	at := s.Time(200)
+	s.ZoomAt(200, 2, bound)
+	if want := MakeTimeSlice(t0.Add(time.Hour), 4*time.Hour); s.Domain != want || !s.Time(200).Equal(at) {
+		t.Errorf("ZoomAt in fails: got %v", s.Domain)
+	}
+
+	// zoom out around the begining of the domain
+	s.ZoomAt(0, 0.25, bound)
+	if want := MakeTimeSlice(t0.Add(time.Hour), 16*time.Hour); s.Domain != want {
+		t.Errorf("ZoomAt out fails: got %v", s.Domain)
+	}
+	// zoom out is kept within the bound
+	s.ZoomAt(800, 0.8, bound)
+	if want := MakeTimeSlice(t0, 20*time.Hour); s.Domain != want {
+		t.Errorf("ZoomAt out within bound fails: got %v", s.Domain)
+	}
+	s.ZoomAt(400, 0.1, bound)
+	if s.Domain != bound {
+		t.Errorf("ZoomAt out of bound fails: got %v", s.Domain)
+	}
+	s.ZoomAt(400, 0, bound)
+	if s.Domain != bound {
+		t.Errorf("ZoomAt with zero factor fails: got %v", s.Domain)
+	}
+
+	// dragging to the right by 100 pixels shows earlier times
+	s = NewScale(MakeTimeSlice(t0.Add(8*time.Hour), 8*time.Hour), 0, 800)
+	s.Pan(100, bound)
+	if want := MakeTimeSlice(t0.Add(7*time.Hour), 8*time.Hour); s.Domain != want {
+		t.Errorf("Pan fails: got %v", s.Domain)
+	}
+	s.Pan(-10000, bound)
+	if want := MakeTimeSlice(t0.Add(16*time.Hour), 8*time.Hour); s.Domain != want {
+		t.Errorf("Pan within bound fails: got %v", s.Domain)
+	}
+	s.Pan(100, TimeSlice{})
+	if want := MakeTimeSlice(t0.Add(15*time.Hour), 8*time.Hour); s.Domain != want {
+		t.Errorf("Pan without bound fails: got %v", s.Domain)
+	}
+
+	// an inverted scale
+	s = NewScale(MakeTimeSlice(t0.Add(16*time.Hour), -8*time.Hour), 0, 800)
+	s.Pan(100, bound)
+	if want := MakeTimeSlice(t0.Add(17*time.Hour), -8*time.Hour); s.Domain != want {
+		t.Errorf("Pan antichrono fails: got %v", s.Domain)
+	}
+}
+
+func ExampleScale() {
+	ts := MakeTimeSlice(time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC), 10*time.Hour)
+
+	// a chart 1000 pixels wide
+	scale := NewScale(ts, 0, 1000)
+	fmt.Println(scale.Position(time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC)))
+	fmt.Println(scale.Time(600).Format(time.TimeOnly))
+
+	// zoom in twice around the pixel 600
+	scale.ZoomAt(600, 2, TimeSlice{})
+	fmt.Println(scale.Domain)
+
+	// Output:
+	// 250
+	// 14:00:00
+	// { 20240101 11:00:00 UTC - 16:00:00 UTC : 5h }
+}
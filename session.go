@@ -0,0 +1,286 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SessionKind is the kind of a trading session
+type SessionKind int
+
+const (
+	SESSION_PRE       SessionKind = 1 // pre-market
+	SESSION_REGULAR   SessionKind = 2 // regular trading hours
+	SESSION_POST      SessionKind = 3 // post-market, after hours
+	SESSION_OVERNIGHT SessionKind = 4 // overnight, usually crossing midnight
+)
+
+func (kind SessionKind) String() string {
+	switch kind {
+	case SESSION_PRE:
+		return "pre"
+	case SESSION_REGULAR:
+		return "regular"
+	case SESSION_POST:
+		return "post"
+	case SESSION_OVERNIGHT:
+		return "overnight"
+	}
+	return "?"
+}
+
+// SessionHours defines a session of every trading day, with the wall clock of the exchange.
+//
+// If Hours.From is later than Hours.To, the session crosses midnight and starts the day before the trading day,
+// like an overnight session "18:00-09:30" belongs to the trading day where it ends.
+type SessionHours struct {
+	Kind  SessionKind
+	Hours ClockRange
+}
+
+// EarlyClose ends the sessions of a trading day earlier, like on the day after Thanksgiving.
+type EarlyClose struct {
+	Day   time.Time     // the trading day, only its date is used, in the location of the calendar
+	Close time.Duration // the wall clock of the close since midnight
+}
+
+// Session is a trading session within a SessionCalendar.
+type Session struct {
+	TimeSlice
+	Kind SessionKind
+	Day  time.Time // the trading day at midnight, in the location of the calendar
+}
+
+// SessionCalendar describes the trading sessions of an exchange, in its location.
+//
+// Sessions of a trading day are defined with the wall clock so they are right across daylight saving time changes.
+// An holiday closes every session of the trading days it contains, an overnight session starting the day before included.
+// An early close ends the sessions of its trading day at its close: later sessions are cancelled and running sessions are truncated.
+type SessionCalendar struct {
+	Location    *time.Location // UTC if nil
+	TradingDays [7]bool        // indexed by the time.Weekday of the trading day
+	Sessions    []SessionHours // sessions of every trading day
+	Holidays    TimeSliceSet   // non trading days
+	EarlyCloses []EarlyClose
+}
+
+// ParseSessionCalendar parses a session calendar defined with one directive per line, like:
+//
+//	# comments and empty lines are ignored
+//	location America/New_York
+//	days mon-fri
+//	session pre 04:00-09:30
+//	session regular 09:30-16:00
+//	session post 16:00-20:00
+//	session overnight 20:00-04:00
+//	holiday 2024-12-25
+//	earlyclose 2024-11-29 13:00
+//
+// Days are a list of weekdays or ranges of weekdays, separated by commas, Monday to Friday by default.
+// Session kinds are pre, regular, post or overnight, a session crossing midnight belongs to the trading day where it ends.
+// Holidays are a single day or an inclusive range of days, in the location of the calendar.
+func ParseSessionCalendar(text string) (sc SessionCalendar, err error) {
+	earlycloses := make([][2]string, 0)
+	common, err := parseCalendarLines(text, "session calendar", false, func(line string, fields []string) error {
+		switch strings.ToLower(fields[0]) {
+		case "session":
+			if len(fields) != 3 {
+				return fmt.Errorf("invalid session calendar line: %q", line)
+			}
+			kind, err := parseSessionKind(fields[1])
+			if err != nil {
+				return err
+			}
+			hours, err := parseClockRange(fields[2], true)
+			if err != nil {
+				return err
+			}
+			sc.Sessions = append(sc.Sessions, SessionHours{Kind: kind, Hours: hours})
+		case "earlyclose":
+			if len(fields) != 3 {
+				return fmt.Errorf("invalid session calendar line: %q", line)
+			}
+			earlycloses = append(earlycloses, [2]string{fields[1], fields[2]})
+		default:
+			return fmt.Errorf("invalid session calendar line: %q", line)
+		}
+		return nil
+	})
+	if err != nil {
+		return SessionCalendar{}, err
+	}
+	sc.Location, sc.TradingDays, sc.Holidays = common.location, common.days, common.holidays
+
+	// early close days are parsed at the end, in the calendar location
+	for _, ec := range earlycloses {
+		day, err := time.ParseInLocation("2006-01-02", ec[0], sc.Location)
+		if err != nil {
+			return SessionCalendar{}, fmt.Errorf("invalid early close day: %q", ec[0])
+		}
+		closing, err := parseClock(ec[1])
+		if err != nil {
+			return SessionCalendar{}, err
+		}
+		sc.EarlyCloses = append(sc.EarlyCloses, EarlyClose{Day: day, Close: closing})
+	}
+	return sc, nil
+}
+
+// TradingDay returns the sessions of the trading day of dte, sorted by their begining.
+// Returns no sessions if the day is not a trading day or is an holiday.
+func (sc SessionCalendar) TradingDay(dte time.Time) []Session {
+	dte = dte.In(sc.location())
+	Y, M, D := dte.Date()
+	day := time.Date(Y, M, D, 0, 0, 0, 0, dte.Location())
+	if !sc.TradingDays[day.Weekday()] {
+		return nil
+	}
+	for _, holiday := range sc.Holidays {
+		if !startBefore(day, holiday.From) && endAfter(holiday.To, day) {
+			return nil
+		}
+	}
+
+	var closing time.Time
+	for _, ec := range sc.EarlyCloses {
+		eY, eM, eD := ec.Day.In(day.Location()).Date()
+		if eY == Y && eM == M && eD == D {
+			closing = clockOn(day, ec.Close)
+		}
+	}
+
+	sessions := make([]Session, 0, len(sc.Sessions))
+	for _, sh := range sc.Sessions {
+		session := Session{Kind: sh.Kind, Day: day}
+		session.From = clockOn(day, sh.Hours.From)
+		session.To = clockOn(day, sh.Hours.To)
+		if sh.Hours.From > sh.Hours.To {
+			session.From = clockOn(time.Date(Y, M, D-1, 0, 0, 0, 0, day.Location()), sh.Hours.From)
+		}
+		if !closing.IsZero() {
+			if !session.From.Before(closing) {
+				continue
+			}
+			if session.To.After(closing) {
+				session.To = closing
+			}
+		}
+		sessions = append(sessions, session)
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].From.Before(sessions[j].From)
+	})
+	return sessions
+}
+
+// SessionAt returns the session containing t. The begining of a session is included, but not its end.
+//
+// returns false if t is not within a session.
+func (sc SessionCalendar) SessionAt(t time.Time) (Session, bool) {
+	t = t.In(sc.location())
+	// sessions crossing midnight belong to the next trading day
+	for _, dte := range []time.Time{t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())} {
+		for _, session := range sc.TradingDay(dte) {
+			if !t.Before(session.From) && t.Before(session.To) {
+				return session, true
+			}
+		}
+	}
+	return Session{}, false
+}
+
+// NextOpen returns the first session starting after t, whatever its kind.
+//
+// returns false if there's no session starting within the next 10 years.
+func (sc SessionCalendar) NextOpen(t time.Time) (Session, bool) {
+	if len(sc.Sessions) == 0 || sc.TradingDays == [7]bool{} {
+		return Session{}, false
+	}
+	t = t.In(sc.location())
+	for i := 0; i < 366*10; i++ {
+		dte := time.Date(t.Year(), t.Month(), t.Day()+i, 0, 0, 0, 0, t.Location())
+		for _, session := range sc.TradingDay(dte) {
+			if session.From.After(t) {
+				return session, true
+			}
+		}
+	}
+	return Session{}, false
+}
+
+// SessionsWithin returns the sessions overlapping the timeslice, sorted by their begining.
+// Sessions are not truncated by the timeslice. Only sessions of the given kinds are returned, or all sessions without kinds.
+//
+// returns an error wrapping ErrInfinite if the timeslice has an infinite boundary.
+func (sc SessionCalendar) SessionsWithin(within TimeSlice, kinds ...SessionKind) ([]Session, error) {
+	if within.IsInfinite() {
+		return nil, fmt.Errorf("unable to get sessions of an infinite timeslice: %w", ErrInfinite)
+	}
+	within.ForceDirection(Chronological)
+	from := within.From.In(sc.location())
+	// the trading day after the end can start before the end, with a session crossing midnight
+	last := within.To.In(sc.location()).AddDate(0, 0, 1)
+	sessions := make([]Session, 0)
+	for dte := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location()); !dte.After(last); dte = time.Date(dte.Year(), dte.Month(), dte.Day()+1, 0, 0, 0, 0, dte.Location()) {
+		for _, session := range sc.TradingDay(dte) {
+			if !session.From.Before(within.To) || !session.To.After(within.From) {
+				continue
+			}
+			if len(kinds) == 0 || containsKind(kinds, session.Kind) {
+				sessions = append(sessions, session)
+			}
+		}
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].From.Before(sessions[j].From)
+	})
+	return sessions, nil
+}
+
+// OpenSlices returns the times within the timeslice where a session of the given kinds is open, or any session without kinds.
+// The returned set can be used with NewDiscontinuousScale.
+//
+// returns an error wrapping ErrInfinite if the timeslice has an infinite boundary.
+func (sc SessionCalendar) OpenSlices(within TimeSlice, kinds ...SessionKind) (TimeSliceSet, error) {
+	sessions, err := sc.SessionsWithin(within, kinds...)
+	if err != nil {
+		return TimeSliceSet{}, err
+	}
+	open := make(TimeSliceSet, 0, len(sessions))
+	for _, session := range sessions {
+		open = append(open, session.TimeSlice)
+	}
+	return open.Intersect(NewTimeSliceSet(within)), nil
+}
+
+func (sc SessionCalendar) location() *time.Location {
+	if sc.Location == nil {
+		return time.UTC
+	}
+	return sc.Location
+}
+
+// containsKind returns true if kind is in kinds
+func containsKind(kinds []SessionKind, kind SessionKind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSessionKind parses a session kind like "regular"
+func parseSessionKind(str string) (SessionKind, error) {
+	for kind := SESSION_PRE; kind <= SESSION_OVERNIGHT; kind++ {
+		if strings.EqualFold(kind.String(), str) {
+			return kind, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid session kind: %q", str)
+}
@@ -0,0 +1,166 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+const nyseSessions = `
+location America/New_York
+days mon-fri
+session pre 04:00-09:30
+session regular 09:30-16:00
+session post 16:00-20:00
+holiday 2024-11-28
+earlyclose 2024-11-29 13:00
+`
+
+func TestSessionCalendar(t *testing.T) {
+	sc, err := ParseSessionCalendar(nyseSessions)
+	if err != nil {
+		t.Fatalf("ParseSessionCalendar fails: %v", err)
+	}
+	ny := sc.Location
+	dte := func(d, h, m int) time.Time { return time.Date(2024, 11, d, h, m, 0, 0, ny) }
+
+	// which session contains t
+	cases := []struct {
+		t    time.Time
+		ok   bool
+		kind SessionKind
+	}{
+		{dte(27, 3, 59), false, 0},
+		{dte(27, 4, 0), true, SESSION_PRE},
+		{dte(27, 9, 30), true, SESSION_REGULAR},
+		{dte(27, 15, 59), true, SESSION_REGULAR},
+		{dte(27, 16, 0), true, SESSION_POST},
+		{dte(27, 20, 0), false, 0},
+		{dte(28, 10, 0), false, 0}, // holiday
+		{dte(29, 12, 59), true, SESSION_REGULAR},
+		{dte(29, 13, 0), false, 0}, // early close
+		{dte(30, 10, 0), false, 0}, // saturday
+		{dte(27, 14, 0).UTC(), true, SESSION_REGULAR},
+	}
+	for _, c := range cases {
+		session, ok := sc.SessionAt(c.t)
+		if ok != c.ok || session.Kind != c.kind {
+			t.Errorf("SessionAt %v fails: got %v %v", c.t, session.Kind, ok)
+		}
+	}
+
+	// next open
+	next, ok := sc.NextOpen(dte(27, 21, 0))
+	if !ok || !next.From.Equal(dte(29, 4, 0)) || next.Kind != SESSION_PRE || !next.Day.Equal(dte(29, 0, 0)) {
+		t.Errorf("NextOpen fails: got %v", next)
+	}
+	next, ok = sc.NextOpen(dte(29, 9, 30))
+	if !ok || !next.From.Equal(time.Date(2024, 12, 2, 4, 0, 0, 0, ny)) {
+		t.Errorf("NextOpen after an early close fails: got %v", next)
+	}
+
+	// enumerate sessions
+	week := TimeSlice{From: dte(25, 0, 0), To: dte(30, 0, 0)}
+	sessions, err := sc.SessionsWithin(week)
+	if err != nil || len(sessions) != 3*3+2 {
+		t.Errorf("SessionsWithin fails: got %d %v", len(sessions), err)
+	}
+	regular, _ := sc.SessionsWithin(week, SESSION_REGULAR)
+	if len(regular) != 4 || regular[3].To != dte(29, 13, 0) {
+		t.Errorf("SessionsWithin regular fails: got %v", regular)
+	}
+	open, _ := sc.OpenSlices(TimeSlice{From: dte(29, 12, 0), To: dte(30, 0, 0)})
+	if want := NewTimeSliceSet(TimeSlice{From: dte(29, 12, 0), To: dte(29, 13, 0)}); len(open) != 1 || open[0] != want[0] {
+		t.Errorf("OpenSlices fails: got %v", open)
+	}
+	if _, err := sc.SessionsWithin(TimeSlice{From: dte(25, 0, 0)}); !errors.Is(err, ErrInfinite) {
+		t.Errorf("SessionsWithin infinite fails: got %v", err)
+	}
+}
+
+func TestSessionCalendarOvernight(t *testing.T) {
+	// a futures exchange trading nearly 23 hours a day, from sunday evening to friday afternoon
+	sc, err := ParseSessionCalendar("location America/Chicago\nsession overnight 17:00-16:00")
+	if err != nil {
+		t.Fatalf("ParseSessionCalendar fails: %v", err)
+	}
+	chicago := sc.Location
+	dte := func(d, h int) time.Time { return time.Date(2024, 3, d, h, 0, 0, 0, chicago) }
+
+	// sunday 10th, daylight saving time starts at 2AM
+	session, ok := sc.SessionAt(dte(10, 18))
+	if !ok || !session.Day.Equal(dte(11, 0)) || !session.From.Equal(dte(10, 17)) || !session.To.Equal(dte(11, 16)) {
+		t.Errorf("SessionAt fails: got %v", session)
+	}
+	if session.Duration().Duration != 23*time.Hour {
+		t.Errorf("SessionAt duration fails: got %v", session.Duration())
+	}
+	if _, ok := sc.SessionAt(dte(15, 17)); ok {
+		t.Errorf("SessionAt friday evening fails")
+	}
+	next, ok := sc.NextOpen(dte(15, 16))
+	if !ok || !next.From.Equal(dte(17, 17)) {
+		t.Errorf("NextOpen fails: got %v", next)
+	}
+	sessions, _ := sc.SessionsWithin(TimeSlice{From: dte(11, 12), To: dte(11, 18)})
+	if len(sessions) != 2 || !sessions[1].Day.Equal(dte(12, 0)) {
+		t.Errorf("SessionsWithin fails: got %v", sessions)
+	}
+}
+
+func TestParseSessionCalendarErrors(t *testing.T) {
+	for _, text := range []string{
+		"session lunch 12:00-13:00",
+		"session regular 09:30-09:30",
+		"session regular 09:30",
+		"holiday 2024-12-24T12:00/2024-12-24T18:00",
+		"earlyclose 2024-11-29",
+		"earlyclose 2024-11-29 25:00",
+		"opening 09:00",
+	} {
+		if _, err := ParseSessionCalendar(text); err == nil {
+			t.Errorf("ParseSessionCalendar %q fails: want an error", text)
+		}
+	}
+}
+
+func TestParseSessionCalendarShared(t *testing.T) {
+	// location, days and holidays are parsed the same way than a Calendar
+	text := "# shared\nlocation Europe/Paris\ndays mon-thu\nholiday 2024-12-25\nholiday 2024-08-05/2024-08-16"
+	cal, err := ParseCalendar(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc, err := ParseSessionCalendar(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.Location.String() != cal.Location.String() || sc.TradingDays != cal.WorkingDays || fmt.Sprint(sc.Holidays) != fmt.Sprint(cal.Holidays) {
+		t.Errorf("ParseSessionCalendar fails: got %v %v %v", sc.Location, sc.TradingDays, sc.Holidays)
+	}
+	for _, text := range []string{"location", "days mon-foo", "holiday 2024-13-01"} {
+		if _, err := ParseCalendar(text); err == nil {
+			t.Errorf("ParseCalendar %q fails: want an error", text)
+		}
+		if _, err := ParseSessionCalendar(text); err == nil {
+			t.Errorf("ParseSessionCalendar %q fails: want an error", text)
+		}
+	}
+}
+
+func ExampleSessionCalendar_SessionsWithin() {
+	sc, _ := ParseSessionCalendar(nyseSessions)
+	day := MakeTimeSlice(time.Date(2024, 11, 29, 0, 0, 0, 0, sc.Location), Day)
+	sessions, _ := sc.SessionsWithin(day)
+	for _, session := range sessions {
+		fmt.Printf("%-7s %s - %s\n", session.Kind, session.From.Format("15:04"), session.To.Format("15:04 MST"))
+	}
+
+	// Output:
+	// pre     04:00 - 09:30 EST
+	// regular 09:30 - 13:00 EST
+}
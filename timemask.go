@@ -3,16 +3,25 @@
 
 package timeline
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // TimeMask is used for scanning a TimeSlice and to get the time corresponding to a rounding o'clock period.
+//
+// Masks follow daylight saving time changes in the location of the time, like StepMask does:
+// MASK_MILLISECOND to MASK_HOUR step over o'clock times, while MASK_HOURx4 and upper masks follow the wall clock, so a day always starts at midnight.
 type TimeMask int
 
 // Available Time Masks used for the scanning a TimeSlice.
+//
+// Values are stable and never renumbered, masks added later get new values, so values do not follow the mask durations.
+// MASK_min and MASK_max bound the values of the masks, not their durations.
 const (
 	MASK_NONE      TimeMask = 0
 	MASK_min       TimeMask = 1
-	MASK_SHORTEST  TimeMask = 1
+	MASK_SHORTEST  TimeMask = 1 // the shortest mask from the minute, see MASK_SHORTEST_SUBSECOND
 	MASK_MINUTE    TimeMask = 1
 	MASK_MINUTEx15 TimeMask = 2
 	MASK_HALFHOUR  TimeMask = 3
@@ -23,13 +32,57 @@ const (
 	MASK_MONTH     TimeMask = 8
 	MASK_QUARTER   TimeMask = 9
 	MASK_YEAR      TimeMask = 10
-	MASK_max       TimeMask = 10
+	MASK_WEEK      TimeMask = 11
+
+	MASK_SHORTEST_SUBSECOND TimeMask = 12 // the shortest mask
+	MASK_MILLISECOND        TimeMask = 12
+	MASK_MILLISECONDx100    TimeMask = 13
+	MASK_SECOND             TimeMask = 14
+	MASK_SECONDx5           TimeMask = 15
+	MASK_SECONDx10          TimeMask = 16
+	MASK_SECONDx15          TimeMask = 17
+	MASK_SECONDx30          TimeMask = 18
+	MASK_max                TimeMask = 18
 )
 
+// masksByDuration lists the masks from the shortest to the longest
+var masksByDuration = []TimeMask{
+	MASK_MILLISECOND, MASK_MILLISECONDx100, MASK_SECOND, MASK_SECONDx5, MASK_SECONDx10, MASK_SECONDx15, MASK_SECONDx30,
+	MASK_MINUTE, MASK_MINUTEx15, MASK_HALFHOUR, MASK_HOUR, MASK_HOURx4, MASK_HALFDAY, MASK_DAY, MASK_WEEK, MASK_MONTH, MASK_QUARTER, MASK_YEAR,
+}
+
+// rank returns the rank of the mask ordered by duration, from 1 for the shortest one.
+// MASK_NONE ranks 0, and an unknown mask ranks after MASK_YEAR.
+func (mask TimeMask) rank() int {
+	if mask == MASK_NONE {
+		return 0
+	}
+	for i, m := range masksByDuration {
+		if m == mask {
+			return i + 1
+		}
+	}
+	return len(masksByDuration) + 1
+}
+
 func (mask TimeMask) String() string {
 	switch mask {
 	case MASK_NONE:
 		return "none"
+	case MASK_MILLISECOND:
+		return "millisecond"
+	case MASK_MILLISECONDx100:
+		return "100 milliseconds"
+	case MASK_SECOND:
+		return "second"
+	case MASK_SECONDx5:
+		return "5 seconds"
+	case MASK_SECONDx10:
+		return "10 seconds"
+	case MASK_SECONDx15:
+		return "15 seconds"
+	case MASK_SECONDx30:
+		return "30 seconds"
 	case MASK_MINUTE:
 		return "minute"
 	case MASK_MINUTEx15:
@@ -44,6 +97,8 @@ func (mask TimeMask) String() string {
 		return "half-day"
 	case MASK_DAY:
 		return "day"
+	case MASK_WEEK:
+		return "week"
 	case MASK_MONTH:
 		return "month"
 	case MASK_QUARTER:
@@ -59,11 +114,18 @@ func (mask TimeMask) String() string {
 // https://yourbasic.org/golang/format-parse-string-time-date-example/
 func (mask TimeMask) GetTimeFormat(newt time.Time, formert time.Time) (strfmt string) {
 	switch mask {
+	case MASK_MILLISECOND, MASK_MILLISECONDx100:
+		strfmt = "05.000"
+		if formert.Truncate(time.Minute) != newt.Truncate(time.Minute) {
+			strfmt = "15:04:05.000"
+		}
+	case MASK_SECOND, MASK_SECONDx5, MASK_SECONDx10, MASK_SECONDx15, MASK_SECONDx30:
+		strfmt = "15:04:05"
 	case MASK_MINUTE, MASK_MINUTEx15, MASK_HALFHOUR, MASK_HOUR, MASK_HOURx4:
 		strfmt = "15:04"
 	case MASK_HALFDAY:
 		strfmt = "Mon 02 15:04"
-	case MASK_DAY:
+	case MASK_DAY, MASK_WEEK:
 		strfmt = "Mon 02"
 	case MASK_MONTH:
 		strfmt = "Jan"
@@ -74,21 +136,21 @@ func (mask TimeMask) GetTimeFormat(newt time.Time, formert time.Time) (strfmt st
 	}
 
 	var upfront string
-	if formert.Day() != newt.Day() && mask < MASK_HALFDAY {
+	if formert.Day() != newt.Day() && mask.rank() < MASK_HALFDAY.rank() {
 		upfront = "Mon 02, "
 	}
-	if formert.Month() != newt.Month() && mask < MASK_MONTH {
+	if formert.Month() != newt.Month() && mask.rank() < MASK_MONTH.rank() {
 		upfront = "Jan, "
-		if mask < MASK_DAY {
+		if mask.rank() < MASK_HALFDAY.rank() {
 			upfront += "Mon 02, "
 		}
 	}
-	if formert.Year() != newt.Year() && mask < MASK_YEAR {
+	if formert.Year() != newt.Year() && mask.rank() < MASK_QUARTER.rank() {
 		upfront = "2006, "
-		if mask < MASK_QUARTER {
+		if mask.rank() < MASK_MONTH.rank() {
 			upfront += "Jan, "
 		}
-		if mask < MASK_DAY {
+		if mask.rank() < MASK_HALFDAY.rank() {
 			upfront += "Mon 02, "
 		}
 	}
@@ -97,119 +159,88 @@ func (mask TimeMask) GetTimeFormat(newt time.Time, formert time.Time) (strfmt st
 	return strfmt
 }
 
+// Format returns newt formated with the time format returned by GetTimeFormat.
+//
+// Weeks are labeled with the ISO 8601 week-year and week number of newt like "2024-W07".
+func (mask TimeMask) Format(newt time.Time, formert time.Time) string {
+	if mask == MASK_WEEK {
+		y, w := newt.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", y, w)
+	}
+	return newt.Format(mask.GetTimeFormat(newt, formert))
+}
+
 // Apply the mask to a date and returns a masked time and a flag indicating if the given time matches exactly the mask
 //
-// if mask is MASK_NONE then returned an unchanged time.
+// if mask is MASK_NONE then returned an unchanged time. An unknown mask returns an unchanged time and false.
 func (mask TimeMask) Apply(t time.Time) (masked time.Time, exactMatch bool) {
-	// extract time components
-	Y := t.Year()
-	M := t.Month()
-	d := t.Day()
-	h := t.Hour()
-	m := t.Minute()
-	loc := t.Location()
-
-	// apply
-	switch mask {
-	case MASK_NONE:
-		masked = t
-	case MASK_MINUTE:
-		masked = time.Date(Y, M, d, h, m, 0, 0, loc)
-	case MASK_MINUTEx15:
-		masked = time.Date(Y, M, d, h, m/15*15, 0, 0, loc)
-	case MASK_HALFHOUR:
-		masked = time.Date(Y, M, d, h, m/30*30, 0, 0, loc)
-	case MASK_HOUR:
-		masked = time.Date(Y, M, d, h, 0, 0, 0, loc)
-	case MASK_HOURx4:
-		masked = time.Date(Y, M, d, h/4*4, 0, 0, 0, loc)
-	case MASK_HALFDAY:
-		masked = time.Date(Y, M, d, h/12*12, 0, 0, 0, loc)
-	case MASK_DAY:
-		masked = time.Date(Y, M, d, 0, 0, 0, 0, loc)
-	case MASK_MONTH:
-		masked = time.Date(Y, M, 1, 0, 0, 0, 0, loc)
-	case MASK_QUARTER:
-		masked = time.Date(Y, (M/3*3)+1, 1, 0, 0, 0, 0, loc)
-	case MASK_YEAR:
-		masked = time.Date(Y, 1, 1, 0, 0, 0, 0, loc)
-	default:
-		panic("unmanaged mask")
+	if mask == MASK_NONE {
+		return t, true
 	}
-	return masked, masked.Equal(t)
+	return mask.step().Apply(t)
 }
 
 // Add applies the mask and adds the mask increment to the given time.
 func (mask TimeMask) Add(t time.Time) time.Time {
-	t, _ = mask.Apply(t)
-	switch mask {
-	case MASK_MINUTE:
-		t = t.Add(time.Minute)
-	case MASK_MINUTEx15:
-		t = t.Add(time.Minute * 15)
-	case MASK_HALFHOUR:
-		t = t.Add(time.Minute * 30)
-	case MASK_HOUR:
-		t = t.Add(time.Hour)
-	case MASK_HOURx4:
-		t = t.Add(time.Hour * 4)
-	case MASK_HALFDAY:
-		t = t.Add(time.Hour * 12)
-	case MASK_DAY:
-		t = t.Add(time.Hour * 24)
-	case MASK_MONTH:
-		if t.Month() == 12 {
-			t = time.Date(t.Year()+1, 1, 1, 0, 0, 0, 0, t.Location())
-		} else {
-			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
-		}
-	case MASK_QUARTER:
-		if t.Month() > (12 - 3) {
-			t = time.Date(t.Year()+1, 1, 1, 0, 0, 0, 0, t.Location())
-		} else {
-			t = time.Date(t.Year(), t.Month()+(t.Month()/3*3), 1, 0, 0, 0, 0, t.Location())
-		}
-	case MASK_YEAR:
-		t = time.Date(t.Year()+1, 1, 1, 0, 0, 0, 0, t.Location())
+	if mask == MASK_NONE {
+		return t
 	}
-	return t
+	return mask.step().Add(t)
 }
 
 // Sub applies the mask and substitute the mask increment to the given time.
 func (mask TimeMask) Sub(t time.Time) time.Time {
-	t, _ = mask.Apply(t)
+	if mask == MASK_NONE {
+		return t
+	}
+	return mask.step().Sub(t)
+}
+
+// Duration returns the typical duration of the mask increment, with average months and years.
+// returns 0 for MASK_NONE.
+func (mask TimeMask) Duration() time.Duration {
+	return mask.step().Duration()
+}
+
+// step returns the definition of the mask as a StepMask, with an invalid zero unit for an unmanaged mask
+func (mask TimeMask) step() StepMask {
 	switch mask {
+	case MASK_MILLISECOND:
+		return StepMask{Unit: UNIT_MILLISECOND, Multiple: 1}
+	case MASK_MILLISECONDx100:
+		return StepMask{Unit: UNIT_MILLISECOND, Multiple: 100}
+	case MASK_SECOND:
+		return StepMask{Unit: UNIT_SECOND, Multiple: 1}
+	case MASK_SECONDx5:
+		return StepMask{Unit: UNIT_SECOND, Multiple: 5}
+	case MASK_SECONDx10:
+		return StepMask{Unit: UNIT_SECOND, Multiple: 10}
+	case MASK_SECONDx15:
+		return StepMask{Unit: UNIT_SECOND, Multiple: 15}
+	case MASK_SECONDx30:
+		return StepMask{Unit: UNIT_SECOND, Multiple: 30}
 	case MASK_MINUTE:
-		return t.Add(-time.Minute)
+		return StepMask{Unit: UNIT_MINUTE, Multiple: 1}
 	case MASK_MINUTEx15:
-		return t.Add(-time.Minute * 15)
+		return StepMask{Unit: UNIT_MINUTE, Multiple: 15}
 	case MASK_HALFHOUR:
-		return t.Add(-time.Minute * 30)
+		return StepMask{Unit: UNIT_MINUTE, Multiple: 30}
 	case MASK_HOUR:
-		return t.Add(-time.Hour)
+		return StepMask{Unit: UNIT_HOUR, Multiple: 1}
 	case MASK_HOURx4:
-		return t.Add(-time.Hour * 4)
+		return StepMask{Unit: UNIT_HOUR, Multiple: 4}
 	case MASK_HALFDAY:
-		return t.Add(-time.Hour * 12)
+		return StepMask{Unit: UNIT_HOUR, Multiple: 12}
 	case MASK_DAY:
-		return t.Add(-time.Hour * 24)
+		return StepMask{Unit: UNIT_DAY, Multiple: 1}
+	case MASK_WEEK:
+		return StepMask{Unit: UNIT_WEEK, Multiple: 1}
 	case MASK_MONTH:
-		if t.Month() == 1 {
-			t = time.Date(t.Year()-1, 12, 1, 0, 0, 0, 0, t.Location())
-		} else {
-			t = time.Date(t.Year(), t.Month()-1, 1, 0, 0, 0, 0, t.Location())
-		}
-		return t
+		return StepMask{Unit: UNIT_MONTH, Multiple: 1}
 	case MASK_QUARTER:
-		if t.Month() <= 3 {
-			t = time.Date(t.Year()-1, 12, 1, 0, 0, 0, 0, t.Location())
-		} else {
-			t = time.Date(t.Year(), t.Month()-(t.Month()/3*3), 1, 0, 0, 0, 0, t.Location())
-		}
-		return t
+		return StepMask{Unit: UNIT_MONTH, Multiple: 3}
 	case MASK_YEAR:
-		t = time.Date(t.Year()-1, 1, 1, 0, 0, 0, 0, t.Location())
-		return t
+		return StepMask{Unit: UNIT_YEAR, Multiple: 1}
 	}
-	return t
+	return StepMask{}
 }
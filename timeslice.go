@@ -4,9 +4,7 @@
 package timeline
 
 import (
-	"errors"
 	"fmt"
-	"log"
 	"net/url"
 	"strings"
 	"time"
@@ -99,7 +97,7 @@ type TimeSlice struct {
 //   - If d > 0 then the given times represents the begining
 //   - If d < 0 then the given times represents the end
 //
-// panic if the given date is not defined (zero time)
+// panic if the given date is not defined (zero time), use NewTimeSlice to get an error instead.
 func MakeTimeSlice(dte time.Time, d time.Duration) TimeSlice {
 	if dte.IsZero() {
 		panic(dte)
@@ -108,6 +106,16 @@ func MakeTimeSlice(dte time.Time, d time.Duration) TimeSlice {
 	return *ts
 }
 
+// NewTimeSlice creates and returns a new timeslice like MakeTimeSlice does.
+//
+// returns an error wrapping ErrInfinite if the given date is not defined (zero time)
+func NewTimeSlice(dte time.Time, d time.Duration) (TimeSlice, error) {
+	if dte.IsZero() {
+		return TimeSlice{}, fmt.Errorf("unable to make a timeslice from an undefined date: %w", ErrInfinite)
+	}
+	return TimeSlice{From: dte, To: dte.Add(d)}, nil
+}
+
 // String returns default formating: "{ from - to : duration } in the UTC timezone".
 // To get it in local use Format()
 //
@@ -529,6 +537,99 @@ func (tsa TimeSlice) IsOverlapping(tsb TimeSlice) bool {
 	return false
 }
 
+// Intersect returns the timeslice where tsa and tsb overlap.
+//
+// ok is false if tsa and tsb do not overlap, so the intersection is empty. In that case the returned timeslice must be ignored.
+// Timeslices only touching each other intersect on a single date.
+//
+// Unlike IsOverlapping, a zero timeslice is considered as infinite both ways, so it intersects any timeslice.
+//
+// The returned timeslice follows the direction of tsa, and is chronological if tsa direction is undefined.
+func (tsa TimeSlice) Intersect(tsb TimeSlice) (inter TimeSlice, ok bool) {
+	dir := tsa.Direction()
+	tsa.ForceDirection(Chronological)
+	tsb.ForceDirection(Chronological)
+
+	inter.From = tsa.From
+	if startBefore(tsa.From, tsb.From) {
+		inter.From = tsb.From
+	}
+	inter.To = tsa.To
+	if endAfter(tsa.To, tsb.To) {
+		inter.To = tsb.To
+	}
+	if endBeforeStart(inter.To, inter.From) {
+		return TimeSlice{}, false
+	}
+
+	if dir == AntiChronological {
+		inter.ForceDirection(AntiChronological)
+	}
+	return inter, true
+}
+
+// Union returns the smallest timeslice containing both tsa and tsb, even if they do not overlap.
+//
+// A zero timeslice is considered as infinite both ways, so the union with a zero timeslice is a zero timeslice.
+//
+// The returned timeslice follows the direction of tsa, and is chronological if tsa direction is undefined.
+func (tsa TimeSlice) Union(tsb TimeSlice) (union TimeSlice) {
+	dir := tsa.Direction()
+	tsa.ForceDirection(Chronological)
+	tsb.ForceDirection(Chronological)
+
+	union.From = tsa.From
+	if startBefore(tsb.From, tsa.From) {
+		union.From = tsb.From
+	}
+	union.To = tsa.To
+	if endAfter(tsb.To, tsa.To) {
+		union.To = tsb.To
+	}
+
+	if dir == AntiChronological {
+		union.ForceDirection(AntiChronological)
+	}
+	return union
+}
+
+// Subtract cuts tsb out of tsa, and returns the remaining timeslices:
+//   - no timeslice if tsb covers tsa entirely,
+//   - one timeslice if tsb does not overlap tsa or covers one of its boundaries,
+//   - two timeslices if tsb is strictly within tsa.
+//
+// Remaining timeslices start or end at the exact boundaries of tsb. Remaining single dates are dropped, but a single date tsa not overlapping tsb is returned unchanged.
+// A zero timeslice is considered as infinite both ways.
+//
+// The returned timeslices follow the direction of tsa and are ordered according to it.
+func (tsa TimeSlice) Subtract(tsb TimeSlice) []TimeSlice {
+	dir := tsa.Direction()
+	tsa.ForceDirection(Chronological)
+	tsb.ForceDirection(Chronological)
+
+	remains := make([]TimeSlice, 0, 2)
+	if _, ok := tsa.Intersect(tsb); !ok {
+		remains = append(remains, tsa)
+	} else {
+		if startBefore(tsa.From, tsb.From) {
+			remains = append(remains, TimeSlice{From: tsa.From, To: tsb.From})
+		}
+		if endAfter(tsa.To, tsb.To) {
+			remains = append(remains, TimeSlice{From: tsb.To, To: tsa.To})
+		}
+	}
+
+	if dir == AntiChronological {
+		for i, j := 0, len(remains)-1; i < j; i, j = i+1, j-1 {
+			remains[i], remains[j] = remains[j], remains[i]
+		}
+		for i := range remains {
+			remains[i].ForceDirection(AntiChronological)
+		}
+	}
+	return remains
+}
+
 // IsInfinite returns true if at least one boundary is a zero time
 func (ts TimeSlice) IsInfinite() bool {
 	if ts.From.IsZero() || ts.To.IsZero() {
@@ -649,35 +750,19 @@ func (ts TimeSlice) WhatTime(rate float64) time.Time {
 // The end of a slice is the exact time of the begining of the next one.
 // The last slice duration can be lower than d duration if thists duration is not a multiple of d.
 //
-// returns an error if a boundary is infinite.
-//
-// panic if d is <= 0
+// returns an error wrapping ErrInfinite if a boundary is infinite, or wrapping ErrNonPositiveStep if d is <= 0.
 func (ts TimeSlice) Split(d time.Duration) ([]TimeSlice, error) {
 	if d <= 0 {
-		log.Fatalf("TimeSlice.Split with invalid duration: %v", d)
+		return []TimeSlice{}, fmt.Errorf("unable to split a timeslice by %v: %w", d, ErrNonPositiveStep)
 	}
 
 	// check duration of ts
 	if ts.IsInfinite() {
-		return []TimeSlice{}, errors.New("unable to split an infinite timeslice")
-	}
-	dur := ts.Duration()
-	if dur.Duration < 0 {
-		d = -d
+		return []TimeSlice{}, fmt.Errorf("unable to split an infinite timeslice: %w", ErrInfinite)
 	}
-
 	slices := make([]TimeSlice, 0)
-	for {
-		split := MakeTimeSlice(ts.From, d)
-		if dur.Duration > 0 && split.To.After(ts.To) || dur.Duration < 0 && split.To.Before(ts.To) {
-			split.To = ts.To
-			if split.Duration().Duration != 0 {
-				slices = append(slices, split)
-			}
-			break
-		}
+	for split := range ts.Chunks(d) {
 		slices = append(slices, split)
-		ts.From = split.To
 	}
 	return slices, nil
 }
@@ -686,6 +771,9 @@ func (ts TimeSlice) Split(d time.Duration) ([]TimeSlice, error) {
 // The returned mask can be used directly by the scan function.
 //   - returns MASK_NONE if the timeslice has infinite duration or maxScans = 0
 //   - returns MASK_SHORTEST if the timselice is a single date
+//
+// A single date keeps getting the minute, as it did before sub-minute masks, although a timeslice of a few milliseconds
+// gets MASK_MILLISECOND: without a duration there's no precision to follow, and callers formatting a single date expect minutes.
 func (ts TimeSlice) GetScanMask(maxScans uint) (mask TimeMask) {
 	if ts.IsInfinite() || maxScans == 0 {
 		return MASK_NONE
@@ -704,6 +792,20 @@ func (ts TimeSlice) GetScanMask(maxScans uint) (mask TimeMask) {
 	//log.Printf("m=%f h=%f d=%f M=%f Y=%f ", time.Duration(d).Minutes(), time.Duration(d).Hours(), d.Days(), d.Months(), d.Years())
 
 	switch {
+	case d.Seconds()*1000 <= float64(maxScans):
+		mask = MASK_MILLISECOND
+	case d.Seconds()*10 <= float64(maxScans):
+		mask = MASK_MILLISECONDx100
+	case d.Seconds() <= float64(maxScans):
+		mask = MASK_SECOND
+	case d.Seconds()/5 <= float64(maxScans):
+		mask = MASK_SECONDx5
+	case d.Seconds()/10 <= float64(maxScans):
+		mask = MASK_SECONDx10
+	case d.Seconds()/15 <= float64(maxScans):
+		mask = MASK_SECONDx15
+	case d.Seconds()/30 <= float64(maxScans):
+		mask = MASK_SECONDx30
 	case d.Minutes() <= float64(maxScans):
 		mask = MASK_MINUTE
 	case (d.Hours() * 4) <= float64(maxScans):
@@ -718,6 +820,8 @@ func (ts TimeSlice) GetScanMask(maxScans uint) (mask TimeMask) {
 		mask = MASK_HALFDAY
 	case d.Days() <= float64(maxScans):
 		mask = MASK_DAY
+	case d.Weeks() <= float64(maxScans):
+		mask = MASK_WEEK
 	case d.Months() <= float64(maxScans):
 		mask = MASK_MONTH
 	case d.Quarters() <= float64(maxScans):
@@ -728,6 +832,44 @@ func (ts TimeSlice) GetScanMask(maxScans uint) (mask TimeMask) {
 	return mask
 }
 
+// GetScanMaskAmong returns the best appropriate mask among candidates for scanning a timeline and to ensure max Scans in a timeslice:
+// the candidate with the shortest duration giving at most maxScans steps, or the longest candidate if none fits.
+// The returned mask can be used directly by the scan function.
+//   - returns MASK_NONE if the timeslice has infinite duration or maxScans = 0 or without candidates
+//   - returns the shortest candidate if the timselice is a single date
+//   - nil candidates are ignored
+//
+// GetScanMask keeps returning a TimeMask, changing its signature to accept and return a Mask would break its callers within the v2 module.
+func (ts TimeSlice) GetScanMaskAmong(maxScans uint, candidates ...Mask) (mask Mask) {
+	if ts.IsInfinite() || maxScans == 0 {
+		return MASK_NONE
+	}
+	d := ts.Duration().Abs()
+	var longest Mask
+	for _, candidate := range candidates {
+		if candidate == nil {
+			continue
+		}
+		cd := candidate.Duration()
+		if cd <= 0 {
+			continue
+		}
+		if longest == nil || cd > longest.Duration() {
+			longest = candidate
+		}
+		if float64(d.Duration)/float64(cd) <= float64(maxScans) && (mask == nil || cd < mask.Duration()) {
+			mask = candidate
+		}
+	}
+	switch {
+	case mask != nil:
+		return mask
+	case longest != nil:
+		return longest
+	}
+	return MASK_NONE
+}
+
 // Scan returns next time, within the timeslice boundaries, matching mask.
 //
 // Scan always starts by the begining of the timeslice. If the begining is infinite then Scan returns a zero date and the cursor is reset to nil.
@@ -739,10 +881,12 @@ func (ts TimeSlice) GetScanMask(maxScans uint) (mask TimeMask) {
 //
 // If the timeslice has an infinite end boundary, then the scan will never returns a nil cursor.
 //
-// panic if mask not an allowed value
-func (ts TimeSlice) Scan(cursor *time.Time, mask TimeMask, fBoundaries bool) time.Time {
-	if mask < MASK_min || mask > MASK_max {
-		log.Fatalf("invalid scan mask: %d", mask)
+// If mask can not be used for scanning, Scan returns a zero time and reset the cursor, like at the end of the scan.
+// Use ScanErr to get an error in this case.
+func (ts TimeSlice) Scan(cursor *time.Time, mask Mask, fBoundaries bool) time.Time {
+	if !isValidMask(mask) {
+		*cursor = time.Time{}
+		return time.Time{}
 	}
 	if ts.From.IsZero() {
 		return time.Time{}
@@ -799,8 +943,8 @@ func (ts TimeSlice) Scan(cursor *time.Time, mask TimeMask, fBoundaries bool) tim
 		// move the cursor one step
 		newcursor = mask.Add(newcursor)
 
-		// check end boundary
-		if newcursor.After(ts.To) {
+		// check end boundary, if any
+		if !ts.To.IsZero() && newcursor.After(ts.To) {
 			if fBoundaries && !cursor.Equal(ts.To) {
 				// returns the end of the timeslice
 				newcursor = ts.To
@@ -815,6 +959,16 @@ func (ts TimeSlice) Scan(cursor *time.Time, mask TimeMask, fBoundaries bool) tim
 	return newcursor
 }
 
+// ScanErr works like Scan, but returns an error wrapping ErrInvalidMask if mask can not be used for scanning, see CheckMask,
+// so an invalid mask is not mistaken for the end of the scan. The cursor is reset in this case.
+func (ts TimeSlice) ScanErr(cursor *time.Time, mask Mask, fBoundaries bool) (time.Time, error) {
+	if err := CheckMask(mask); err != nil {
+		*cursor = time.Time{}
+		return time.Time{}, err
+	}
+	return ts.Scan(cursor, mask, fBoundaries), nil
+}
+
 // FormatQuery return a query string in the following format
 //
 //	"from=20060102-150405;to=20060102-150405"
@@ -368,3 +368,125 @@ func TestIsOverlapping(t *testing.T) {
 	}
 
 }
+
+func TestIntersect(t *testing.T) {
+	t0 := time.Date(2022, 6, 10, 0, 0, 0, 0, time.UTC)
+	tsa := MakeTimeSlice(t0, 10*Day)
+
+	// overlapping
+	inter, ok := tsa.Intersect(MakeTimeSlice(t0.Add(5*Day), 10*Day))
+	if !ok || inter.Compare(TimeSlice{From: t0.Add(5 * Day), To: t0.Add(10 * Day)}) != EQUAL {
+		t.Errorf("Intersect overlapping fails: got %v %v", inter, ok)
+	}
+
+	// not overlapping
+	if _, ok := tsa.Intersect(MakeTimeSlice(t0.Add(11*Day), Day)); ok {
+		t.Error("Intersect not overlapping fails")
+	}
+
+	// touching on a single date
+	inter, ok = tsa.Intersect(MakeTimeSlice(t0.Add(10*Day), Day))
+	if !ok || !inter.From.Equal(t0.Add(10*Day)) || !inter.To.Equal(t0.Add(10*Day)) {
+		t.Errorf("Intersect touching fails: got %v %v", inter, ok)
+	}
+
+	// antichronological receiver keeps its direction
+	tsanti := MakeTimeSlice(t0.Add(10*Day), -10*Day)
+	inter, ok = tsanti.Intersect(MakeTimeSlice(t0.Add(5*Day), 10*Day))
+	if !ok || inter.Compare(TimeSlice{From: t0.Add(10 * Day), To: t0.Add(5 * Day)}) != EQUAL {
+		t.Errorf("Intersect antichrono fails: got %v %v", inter, ok)
+	}
+
+	// infinite boundaries
+	inter, ok = TimeSlice{From: t0.Add(5 * Day)}.Intersect(TimeSlice{To: t0.Add(8 * Day)})
+	if !ok || inter.Compare(TimeSlice{From: t0.Add(5 * Day), To: t0.Add(8 * Day)}) != EQUAL {
+		t.Errorf("Intersect infinite fails: got %v %v", inter, ok)
+	}
+	if _, ok = (TimeSlice{From: t0.Add(5 * Day)}).Intersect(TimeSlice{To: t0}); ok {
+		t.Error("Intersect infinite not overlapping fails")
+	}
+	inter, ok = TimeSlice{}.Intersect(tsa)
+	if !ok || inter.Compare(tsa) != EQUAL {
+		t.Errorf("Intersect zero fails: got %v %v", inter, ok)
+	}
+}
+
+func TestUnion(t *testing.T) {
+	t0 := time.Date(2022, 6, 10, 0, 0, 0, 0, time.UTC)
+	tsa := MakeTimeSlice(t0, 2*Day)
+
+	union := tsa.Union(MakeTimeSlice(t0.Add(5*Day), Day))
+	if union.Compare(TimeSlice{From: t0, To: t0.Add(6 * Day)}) != EQUAL {
+		t.Errorf("Union fails: got %v", union)
+	}
+
+	union = MakeTimeSlice(t0.Add(2*Day), -2*Day).Union(MakeTimeSlice(t0.Add(5*Day), Day))
+	if union.Compare(TimeSlice{From: t0.Add(6 * Day), To: t0}) != EQUAL {
+		t.Errorf("Union antichrono fails: got %v", union)
+	}
+
+	union = tsa.Union(TimeSlice{To: t0.Add(Day)})
+	if union.Compare(TimeSlice{To: t0.Add(2 * Day)}) != EQUAL {
+		t.Errorf("Union infinite fails: got %v", union)
+	}
+
+	if union = tsa.Union(TimeSlice{}); !union.IsZero() {
+		t.Errorf("Union zero fails: got %v", union)
+	}
+}
+
+func TestSubtract(t *testing.T) {
+	t0 := time.Date(2022, 6, 10, 0, 0, 0, 0, time.UTC)
+	tsa := MakeTimeSlice(t0, 10*Day)
+
+	// two pieces
+	remains := tsa.Subtract(MakeTimeSlice(t0.Add(2*Day), 3*Day))
+	if len(remains) != 2 ||
+		remains[0].Compare(TimeSlice{From: t0, To: t0.Add(2 * Day)}) != EQUAL ||
+		remains[1].Compare(TimeSlice{From: t0.Add(5 * Day), To: t0.Add(10 * Day)}) != EQUAL {
+		t.Errorf("Subtract two pieces fails: got %v", remains)
+	}
+
+	// two pieces, antichronological
+	remains = MakeTimeSlice(t0.Add(10*Day), -10*Day).Subtract(MakeTimeSlice(t0.Add(2*Day), 3*Day))
+	if len(remains) != 2 ||
+		remains[0].Compare(TimeSlice{From: t0.Add(10 * Day), To: t0.Add(5 * Day)}) != EQUAL ||
+		remains[1].Compare(TimeSlice{From: t0.Add(2 * Day), To: t0}) != EQUAL {
+		t.Errorf("Subtract antichrono fails: got %v", remains)
+	}
+
+	// one piece
+	remains = tsa.Subtract(TimeSlice{From: t0.Add(4 * Day)})
+	if len(remains) != 1 || remains[0].Compare(TimeSlice{From: t0, To: t0.Add(4 * Day)}) != EQUAL {
+		t.Errorf("Subtract one piece fails: got %v", remains)
+	}
+
+	// no overlap
+	remains = tsa.Subtract(MakeTimeSlice(t0.Add(20*Day), Day))
+	if len(remains) != 1 || remains[0].Compare(tsa) != EQUAL {
+		t.Errorf("Subtract no overlap fails: got %v", remains)
+	}
+
+	// a single date not overlapping
+	single := TimeSlice{From: t0.Add(-Day), To: t0.Add(-Day)}
+	if remains = single.Subtract(tsa); len(remains) != 1 || remains[0].Compare(single) != EQUAL {
+		t.Errorf("Subtract single date no overlap fails: got %v", remains)
+	}
+	if remains = (TimeSlice{From: t0, To: t0}).Subtract(tsa); len(remains) != 0 {
+		t.Errorf("Subtract single date within fails: got %v", remains)
+	}
+
+	// nothing left
+	if remains = tsa.Subtract(TimeSlice{}); len(remains) != 0 {
+		t.Errorf("Subtract all fails: got %v", remains)
+	}
+	if remains = tsa.Subtract(tsa); len(remains) != 0 {
+		t.Errorf("Subtract itself fails: got %v", remains)
+	}
+
+	// from an infinite timeslice
+	remains = TimeSlice{}.Subtract(tsa)
+	if len(remains) != 2 || remains[0].Compare(TimeSlice{To: t0}) != EQUAL || remains[1].Compare(TimeSlice{From: t0.Add(10 * Day)}) != EQUAL {
+		t.Errorf("Subtract from zero fails: got %v", remains)
+	}
+}
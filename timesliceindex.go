@@ -0,0 +1,231 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import "time"
+
+// IndexItem is a timeslice stored in a TimeSliceIndex with its attached value.
+type IndexItem[T comparable] struct {
+	TimeSlice
+	Value T
+}
+
+// TimeSliceIndex indexes timeslices with attached values, to quickly find
+// the ones containing a time or overlapping another timeslice.
+//
+// The index is an augmented interval tree, kept balanced, so Insert, Delete and queries run in logarithmic time,
+// plus the number of returned items for queries.
+//
+// Timeslices can have infinite boundaries, a zero timeslice contains all times. Boundaries are included,
+// so timeslices only touching each other are overlapping, like with TimeSlice.IsOverlapping.
+//
+// The zero value is an empty index ready to use. A TimeSliceIndex is not safe for concurrent use.
+type TimeSliceIndex[T comparable] struct {
+	root *indexNode[T]
+	len  int
+}
+
+type indexNode[T comparable] struct {
+	item   IndexItem[T]
+	key    TimeSlice // the item timeslice in the chronological direction
+	maxTo  time.Time // the greatest end within the subtree, zero for an infinite future
+	height int
+	left   *indexNode[T]
+	right  *indexNode[T]
+}
+
+// Len returns the number of items in the index.
+func (idx *TimeSliceIndex[T]) Len() int {
+	return idx.len
+}
+
+// Insert adds the timeslice ts with its attached value into the index.
+// The same timeslice can be inserted many times.
+func (idx *TimeSliceIndex[T]) Insert(ts TimeSlice, value T) {
+	n := &indexNode[T]{item: IndexItem[T]{TimeSlice: ts, Value: value}, key: ts, height: 1}
+	n.key.ForceDirection(Chronological)
+	n.maxTo = n.key.To
+	idx.root = idx.root.insert(n)
+	idx.len++
+}
+
+// Delete removes one item matching exactly the timeslice ts, in the same direction, and the value.
+// Returns false if no matching item has been found.
+func (idx *TimeSliceIndex[T]) Delete(ts TimeSlice, value T) bool {
+	key := ts
+	key.ForceDirection(Chronological)
+	var found bool
+	idx.root, found = idx.root.remove(key, IndexItem[T]{TimeSlice: ts, Value: value})
+	if found {
+		idx.len--
+	}
+	return found
+}
+
+// Containing returns all items containing t, sorted by their begining.
+//
+// returns nil if t is a zero time.
+func (idx *TimeSliceIndex[T]) Containing(t time.Time) []IndexItem[T] {
+	if t.IsZero() {
+		return nil
+	}
+	return idx.Overlapping(TimeSlice{From: t, To: t})
+}
+
+// Overlapping returns all items overlapping q, sorted by their begining.
+//
+// Like with TimeSlice.IsOverlapping, a zero q does not overlap any item, use All to get all items.
+func (idx *TimeSliceIndex[T]) Overlapping(q TimeSlice) []IndexItem[T] {
+	items := make([]IndexItem[T], 0)
+	if q.IsZero() {
+		return items
+	}
+	q.ForceDirection(Chronological)
+	idx.root.overlapping(q, &items)
+	return items
+}
+
+// All returns all items, sorted by their begining.
+func (idx *TimeSliceIndex[T]) All() []IndexItem[T] {
+	items := make([]IndexItem[T], 0, idx.len)
+	idx.root.all(&items)
+	return items
+}
+
+func (n *indexNode[T]) all(items *[]IndexItem[T]) {
+	if n == nil {
+		return
+	}
+	n.left.all(items)
+	*items = append(*items, n.item)
+	n.right.all(items)
+}
+
+func (n *indexNode[T]) overlapping(q TimeSlice, items *[]IndexItem[T]) {
+	// no item of this subtree ends after the begining of q
+	if n == nil || endBeforeStart(n.maxTo, q.From) {
+		return
+	}
+	n.left.overlapping(q, items)
+	// all items of the right subtree starts after the end of q
+	if endBeforeStart(q.To, n.key.From) {
+		return
+	}
+	if !endBeforeStart(n.key.To, q.From) {
+		*items = append(*items, n.item)
+	}
+	n.right.overlapping(q, items)
+}
+
+func (n *indexNode[T]) insert(newn *indexNode[T]) *indexNode[T] {
+	if n == nil {
+		return newn
+	}
+	if startBefore(newn.key.From, n.key.From) {
+		n.left = n.left.insert(newn)
+	} else {
+		n.right = n.right.insert(newn)
+	}
+	return n.rebalance()
+}
+
+func (n *indexNode[T]) remove(key TimeSlice, item IndexItem[T]) (*indexNode[T], bool) {
+	if n == nil {
+		return nil, false
+	}
+	var found bool
+	switch {
+	case startBefore(key.From, n.key.From):
+		n.left, found = n.left.remove(key, item)
+	case startBefore(n.key.From, key.From):
+		n.right, found = n.right.remove(key, item)
+	case n.item.Value == item.Value && n.item.Compare(item.TimeSlice) == EQUAL:
+		if n.left == nil {
+			return n.right, true
+		}
+		if n.right == nil {
+			return n.left, true
+		}
+		// replace the node by its successor
+		var succ *indexNode[T]
+		n.right, succ = n.right.removeMin()
+		succ.left = n.left
+		succ.right = n.right
+		return succ.rebalance(), true
+	default:
+		// same begining, the matching item can be on both sides
+		n.left, found = n.left.remove(key, item)
+		if !found {
+			n.right, found = n.right.remove(key, item)
+		}
+	}
+	if !found {
+		return n, false
+	}
+	return n.rebalance(), true
+}
+
+func (n *indexNode[T]) removeMin() (root *indexNode[T], min *indexNode[T]) {
+	if n.left == nil {
+		return n.right, n
+	}
+	n.left, min = n.left.removeMin()
+	return n.rebalance(), min
+}
+
+func (n *indexNode[T]) getHeight() int {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+// update recalculates the height and the max end of the node from its children
+func (n *indexNode[T]) update() {
+	n.height = 1 + max(n.left.getHeight(), n.right.getHeight())
+	n.maxTo = n.key.To
+	if n.left != nil && endAfter(n.left.maxTo, n.maxTo) {
+		n.maxTo = n.left.maxTo
+	}
+	if n.right != nil && endAfter(n.right.maxTo, n.maxTo) {
+		n.maxTo = n.right.maxTo
+	}
+}
+
+func (n *indexNode[T]) rotateLeft() *indexNode[T] {
+	r := n.right
+	n.right = r.left
+	r.left = n
+	n.update()
+	r.update()
+	return r
+}
+
+func (n *indexNode[T]) rotateRight() *indexNode[T] {
+	l := n.left
+	n.left = l.right
+	l.right = n
+	n.update()
+	l.update()
+	return l
+}
+
+// rebalance updates the node and applies AVL rotations if required
+func (n *indexNode[T]) rebalance() *indexNode[T] {
+	n.update()
+	balance := n.left.getHeight() - n.right.getHeight()
+	switch {
+	case balance > 1:
+		if n.left.left.getHeight() < n.left.right.getHeight() {
+			n.left = n.left.rotateLeft()
+		}
+		return n.rotateRight()
+	case balance < -1:
+		if n.right.right.getHeight() < n.right.left.getHeight() {
+			n.right = n.right.rotateRight()
+		}
+		return n.rotateLeft()
+	}
+	return n
+}
@@ -0,0 +1,178 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// randomSlots returns n random timeslices within a year, some of them being antichronological or half infinite
+func randomSlots(n int, seed int64) []TimeSlice {
+	rnd := rand.New(rand.NewSource(seed))
+	t0 := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	slots := make([]TimeSlice, n)
+	for i := range slots {
+		from := t0.Add(time.Duration(rnd.Int63n(int64(Year))))
+		slots[i] = MakeTimeSlice(from, time.Duration(rnd.Int63n(int64(4*time.Hour))))
+		switch rnd.Intn(50) {
+		case 0:
+			slots[i].From = time.Time{}
+		case 1:
+			slots[i].To = time.Time{}
+		case 2:
+			slots[i].ForceDirection(AntiChronological)
+		}
+	}
+	return slots
+}
+
+func TestTimeSliceIndex(t *testing.T) {
+	slots := randomSlots(2000, 1)
+	var idx TimeSliceIndex[int]
+	for i, ts := range slots {
+		idx.Insert(ts, i)
+	}
+	if idx.Len() != len(slots) {
+		t.Fatalf("Len fails: got %d", idx.Len())
+	}
+
+	// remove one item out of 3
+	for i := 0; i < len(slots); i += 3 {
+		if !idx.Delete(slots[i], i) {
+			t.Fatalf("Delete fails: %d %v not found", i, slots[i])
+		}
+	}
+	if idx.Delete(slots[0], 0) {
+		t.Error("Delete fails: deleted twice")
+	}
+
+	queries := randomSlots(200, 2)
+	for _, q := range queries {
+		want := make(map[int]bool)
+		for i, ts := range slots {
+			if i%3 != 0 && ts.IsOverlapping(q) {
+				want[i] = true
+			}
+		}
+		got := idx.Overlapping(q)
+		if len(got) != len(want) {
+			t.Fatalf("Overlapping %v fails: want %d items, got %d", q, len(want), len(got))
+		}
+		for i, item := range got {
+			if !want[item.Value] {
+				t.Errorf("Overlapping %v fails: unexpected item %v", q, item)
+			}
+			if i > 0 {
+				prev, cur := got[i-1].TimeSlice, item.TimeSlice
+				prev.ForceDirection(Chronological)
+				cur.ForceDirection(Chronological)
+				if startBefore(cur.From, prev.From) {
+					t.Errorf("Overlapping %v fails: unsorted items", q)
+				}
+			}
+		}
+
+		at := q.Middle()
+		if at.IsZero() {
+			continue
+		}
+		ncontains := 0
+		for i, ts := range slots {
+			if i%3 != 0 && ts.WhereIs(at)&TS_IN > 0 {
+				ncontains++
+			}
+		}
+		if got := idx.Containing(at); len(got) != ncontains {
+			t.Errorf("Containing %v fails: want %d items, got %d", at, ncontains, len(got))
+		}
+	}
+
+	// a zero timeslice contains all times
+	idx.Insert(TimeSlice{}, -1)
+	found := false
+	for _, item := range idx.Containing(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		found = found || item.Value == -1
+	}
+	if !found {
+		t.Error("Containing fails with a zero timeslice")
+	}
+	// a zero query does not overlap anything, like with IsOverlapping
+	if got := idx.Overlapping(TimeSlice{}); len(got) != 0 {
+		t.Errorf("Overlapping fails with a zero query: got %d items", len(got))
+	}
+	all := idx.All()
+	if len(all) != idx.Len() {
+		t.Errorf("All fails: got %d items", len(all))
+	}
+	for i := 1; i < len(all); i++ {
+		prev, cur := all[i-1].TimeSlice, all[i].TimeSlice
+		prev.ForceDirection(Chronological)
+		cur.ForceDirection(Chronological)
+		if startBefore(cur.From, prev.From) {
+			t.Errorf("All fails: unsorted items")
+		}
+	}
+}
+
+func BenchmarkTimeSliceIndexOverlapping(b *testing.B) {
+	slots := randomSlots(20000, 1)
+	queries := randomSlots(1000, 2)
+	var idx TimeSliceIndex[int]
+	for i, ts := range slots {
+		idx.Insert(ts, i)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		idx.Overlapping(queries[i%len(queries)])
+	}
+}
+
+func BenchmarkNaiveOverlapping(b *testing.B) {
+	slots := randomSlots(20000, 1)
+	queries := randomSlots(1000, 2)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		q := queries[i%len(queries)]
+		items := make([]TimeSlice, 0)
+		for _, ts := range slots {
+			if ts.IsOverlapping(q) {
+				items = append(items, ts)
+			}
+		}
+	}
+}
+
+func BenchmarkTimeSliceIndexContaining(b *testing.B) {
+	slots := randomSlots(20000, 1)
+	queries := randomSlots(1000, 2)
+	var idx TimeSliceIndex[int]
+	for i, ts := range slots {
+		idx.Insert(ts, i)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		idx.Containing(queries[i%len(queries)].From)
+	}
+}
+
+func BenchmarkNaiveContaining(b *testing.B) {
+	slots := randomSlots(20000, 1)
+	queries := randomSlots(1000, 2)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		at := queries[i%len(queries)].From
+		items := make([]TimeSlice, 0)
+		for _, ts := range slots {
+			if ts.WhereIs(at)&TS_IN > 0 {
+				items = append(items, ts)
+			}
+		}
+	}
+}
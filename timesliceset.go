@@ -0,0 +1,181 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"sort"
+	"time"
+)
+
+// TimeSliceSet represents a set of times, as a list of disjoint and chronological timeslices sorted by their begining.
+//
+// A zero time boundary is an infinite boundary, so a TimeSliceSet can contain timeslices starting in the past or ending in the future.
+// A zero TimeSlice within a set represents all times.
+//
+// Use NewTimeSliceSet or Normalize to build a valid set from any list of timeslices.
+type TimeSliceSet []TimeSlice
+
+// NewTimeSliceSet builds a normalized set with the given timeslices.
+func NewTimeSliceSet(slices ...TimeSlice) TimeSliceSet {
+	return TimeSliceSet(slices).Normalize()
+}
+
+// Normalize returns a new set where:
+//   - all timeslices are forced in the chronological direction,
+//   - single dates are removed,
+//   - timeslices are sorted by their begining, an infinite begining comes first,
+//   - overlapping or touching timeslices are merged together.
+//
+// The receiver is not changed.
+func (set TimeSliceSet) Normalize() TimeSliceSet {
+	slices := make([]TimeSlice, 0, len(set))
+	for _, ts := range set {
+		if !ts.IsInfinite() && ts.From.Equal(ts.To) {
+			continue
+		}
+		ts.ForceDirection(Chronological)
+		slices = append(slices, ts)
+	}
+	sort.SliceStable(slices, func(i, j int) bool {
+		return startBefore(slices[i].From, slices[j].From)
+	})
+
+	norm := make(TimeSliceSet, 0, len(slices))
+	for _, ts := range slices {
+		last := len(norm) - 1
+		if last >= 0 && !endBeforeStart(norm[last].To, ts.From) {
+			if endAfter(ts.To, norm[last].To) {
+				norm[last].To = ts.To
+			}
+			continue
+		}
+		norm = append(norm, ts)
+	}
+	return norm
+}
+
+// IsEmpty returns true if the set does not contain any time.
+func (set TimeSliceSet) IsEmpty() bool {
+	return len(set) == 0
+}
+
+// Contains returns true if t is within one of the timeslices of the set.
+//
+// The set must be normalized.
+func (set TimeSliceSet) Contains(t time.Time) bool {
+	for _, ts := range set {
+		if ts.IsZero() || ts.WhereIs(t)&TS_IN > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Duration returns the cumulated duration of all timeslices of the set.
+// The returned duration is infinite if one timeslice has an infinite boundary.
+//
+// The set must be normalized.
+func (set TimeSliceSet) Duration() Duration {
+	d := NewDuration(0)
+	for _, ts := range set {
+		tsd := ts.Duration()
+		if !tsd.IsFinite {
+			return Duration{}
+		}
+		d.Duration += tsd.Duration
+	}
+	return d
+}
+
+// Union returns a normalized set with all the times of set or of other.
+func (set TimeSliceSet) Union(other TimeSliceSet) TimeSliceSet {
+	all := make(TimeSliceSet, 0, len(set)+len(other))
+	all = append(all, set...)
+	all = append(all, other...)
+	return all.Normalize()
+}
+
+// Intersect returns a normalized set with the times both in set and in other.
+//
+// Timeslices only touching each other do not produce any single date in the result.
+func (set TimeSliceSet) Intersect(other TimeSliceSet) TimeSliceSet {
+	a := set.Normalize()
+	b := other.Normalize()
+
+	inter := make(TimeSliceSet, 0)
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		if ts, ok := a[i].Intersect(b[j]); ok && (ts.IsInfinite() || !ts.From.Equal(ts.To)) {
+			inter = append(inter, ts)
+		}
+
+		// move forward the timeslice ending first
+		if endAfter(a[i].To, b[j].To) {
+			j++
+		} else {
+			i++
+		}
+	}
+	return inter
+}
+
+// Subtract returns a normalized set with the times of set which are not in other.
+func (set TimeSliceSet) Subtract(other TimeSliceSet) TimeSliceSet {
+	return set.Intersect(other.Complement())
+}
+
+// Complement returns a normalized set with all the times which are not in set.
+//
+// The complement of an empty set is a set with a single zero TimeSlice, representing all times.
+// The complement of a set containing all times is an empty set.
+func (set TimeSliceSet) Complement() TimeSliceSet {
+	norm := set.Normalize()
+
+	compl := make(TimeSliceSet, 0, len(norm)+1)
+	var from time.Time // the past
+	for _, ts := range norm {
+		if !ts.From.IsZero() {
+			compl = append(compl, TimeSlice{From: from, To: ts.From})
+		}
+		if ts.To.IsZero() {
+			// reach the future
+			return compl
+		}
+		from = ts.To
+	}
+	compl = append(compl, TimeSlice{From: from})
+	return compl
+}
+
+// startBefore returns true if the begining a is strictly before the begining b.
+// A zero time is considered as an infinite past.
+func startBefore(a time.Time, b time.Time) bool {
+	if a.IsZero() {
+		return !b.IsZero()
+	}
+	if b.IsZero() {
+		return false
+	}
+	return a.Before(b)
+}
+
+// endAfter returns true if the end a is strictly after the end b.
+// A zero time is considered as an infinite future.
+func endAfter(a time.Time, b time.Time) bool {
+	if a.IsZero() {
+		return !b.IsZero()
+	}
+	if b.IsZero() {
+		return false
+	}
+	return a.After(b)
+}
+
+// endBeforeStart returns true if the end e is strictly before the begining s.
+// A zero end is an infinite future and a zero begining is an infinite past.
+func endBeforeStart(e time.Time, s time.Time) bool {
+	if e.IsZero() || s.IsZero() {
+		return false
+	}
+	return e.Before(s)
+}
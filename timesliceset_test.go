@@ -0,0 +1,140 @@
+// Copyright 2022-2024 by larry868. All rights reserved.
+// Use of this source code is governed by MIT licence that can be found in the LICENSE file.
+
+package timeline
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestTimeSliceSetNormalize(t *testing.T) {
+	t0 := time.Date(2022, 6, 10, 0, 0, 0, 0, time.UTC)
+
+	set := NewTimeSliceSet(
+		MakeTimeSlice(t0.Add(5*Day), 2*Day),   // 15-17 touching
+		MakeTimeSlice(t0.Add(2*Day), -2*Day),  // 10-12 antichrono
+		MakeTimeSlice(t0.Add(1*Day), 3*Day),   // 11-14 overlapping
+		MakeTimeSlice(t0.Add(14*Day), 0),      // single date
+		MakeTimeSlice(t0.Add(4*Day), 1*Day),   // 14-15 touching
+		MakeTimeSlice(t0.Add(20*Day), 1*Day),  // 20-21
+		MakeTimeSlice(t0.Add(20*Day), -1*Day), // 19-20 touching antichrono
+	)
+	want := TimeSliceSet{
+		{From: t0, To: t0.Add(7 * Day)},
+		{From: t0.Add(19 * Day), To: t0.Add(21 * Day)},
+	}
+	if len(set) != len(want) {
+		t.Fatalf("Normalize fails: got %v", set)
+	}
+	for i := range want {
+		if set[i].Compare(want[i]) != EQUAL {
+			t.Errorf("Normalize fails at %d: want %v got %v", i, want[i], set[i])
+		}
+	}
+
+	// infinite boundaries
+	set = NewTimeSliceSet(
+		TimeSlice{From: t0.Add(10 * Day)},
+		MakeTimeSlice(t0, Day),
+		TimeSlice{To: t0.Add(-Day)},
+		MakeTimeSlice(t0.Add(12*Day), Day),
+	)
+	if len(set) != 3 || !set[0].From.IsZero() || !set[2].To.IsZero() || !set[2].From.Equal(t0.Add(10*Day)) {
+		t.Errorf("Normalize infinite fails: got %v", set)
+	}
+
+	set = NewTimeSliceSet(MakeTimeSlice(t0, Day), TimeSlice{})
+	if len(set) != 1 || !set[0].IsZero() {
+		t.Errorf("Normalize all times fails: got %v", set)
+	}
+}
+
+func TestTimeSliceSetOperations(t *testing.T) {
+	t0 := time.Date(2022, 6, 10, 0, 0, 0, 0, time.UTC)
+
+	// service up from t0 to t0+10d (June 10 to 20), and from t0+15d (June 25) onwards
+	up := NewTimeSliceSet(
+		MakeTimeSlice(t0, 10*Day),
+		TimeSlice{From: t0.Add(15 * Day)},
+	)
+	// maintenance from t0+8d to t0+16d (June 18 to 26)
+	maintenance := NewTimeSliceSet(MakeTimeSlice(t0.Add(8*Day), 8*Day))
+
+	union := up.Union(maintenance)
+	if len(union) != 1 || !union[0].From.Equal(t0) || !union[0].To.IsZero() {
+		t.Errorf("Union fails: got %v", union)
+	}
+
+	inter := up.Intersect(maintenance)
+	if len(inter) != 2 ||
+		inter[0].Compare(TimeSlice{From: t0.Add(8 * Day), To: t0.Add(10 * Day)}) != EQUAL ||
+		inter[1].Compare(TimeSlice{From: t0.Add(15 * Day), To: t0.Add(16 * Day)}) != EQUAL {
+		t.Errorf("Intersect fails: got %v", inter)
+	}
+
+	sub := up.Subtract(maintenance)
+	if len(sub) != 2 ||
+		sub[0].Compare(TimeSlice{From: t0, To: t0.Add(8 * Day)}) != EQUAL ||
+		sub[1].Compare(TimeSlice{From: t0.Add(16 * Day)}) != EQUAL {
+		t.Errorf("Subtract fails: got %v", sub)
+	}
+	if d := sub.Duration(); d.IsFinite {
+		t.Errorf("Duration fails: got %v", d)
+	}
+	if d := inter.Duration(); !d.IsFinite || d.Duration != 3*Day {
+		t.Errorf("Duration fails: got %v", d)
+	}
+
+	compl := up.Complement()
+	if len(compl) != 2 ||
+		compl[0].Compare(TimeSlice{To: t0}) != EQUAL ||
+		compl[1].Compare(TimeSlice{From: t0.Add(10 * Day), To: t0.Add(15 * Day)}) != EQUAL {
+		t.Errorf("Complement fails: got %v", compl)
+	}
+	if back := compl.Complement(); len(back) != len(up) || back[0].Compare(up[0]) != EQUAL || back[1].Compare(up[1]) != EQUAL {
+		t.Errorf("Complement twice fails: got %v", back)
+	}
+
+	// touching timeslices do not intersect
+	touch := NewTimeSliceSet(MakeTimeSlice(t0.Add(10*Day), Day)).Intersect(up)
+	if !touch.IsEmpty() {
+		t.Errorf("Intersect touching fails: got %v", touch)
+	}
+
+	// all times and no times
+	all := TimeSliceSet{}.Complement()
+	if len(all) != 1 || !all[0].IsZero() {
+		t.Errorf("Complement of empty fails: got %v", all)
+	}
+	if !all.Complement().IsEmpty() {
+		t.Errorf("Complement of all fails: got %v", all.Complement())
+	}
+	if inter := all.Intersect(maintenance); len(inter) != 1 || inter[0].Compare(maintenance[0]) != EQUAL {
+		t.Errorf("Intersect with all fails: got %v", inter)
+	}
+
+	if !up.Contains(t0.Add(20*Day)) || up.Contains(t0.Add(12*Day)) || !all.Contains(t0) {
+		t.Error("Contains fails")
+	}
+}
+
+func ExampleTimeSliceSet_Subtract() {
+	t0 := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	up := NewTimeSliceSet(MakeTimeSlice(t0, Week))
+	maintenance := NewTimeSliceSet(
+		MakeTimeSlice(t0.Add(2*Day), 4*time.Hour),
+		MakeTimeSlice(t0.Add(5*Day), Day),
+	)
+
+	for _, ts := range up.Subtract(maintenance) {
+		fmt.Println(ts)
+	}
+
+	// Output:
+	// { 20220101 UTC - 20220103 UTC : 2d }
+	// { 20220103 04:00:00 UTC - 20220106 UTC : 2d20h }
+	// { 20220107 UTC - 20220108 UTC : 1d }
+}